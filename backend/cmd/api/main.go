@@ -12,7 +12,7 @@ import (
 	"chi-mysql-boilerplate/internal/server"
 )
 
-func gracefulShutdown(apiServer *http.Server) {
+func gracefulShutdown(apiServer *server.Server, done chan<- struct{}) {
 	// create context that listens for the interrupt signal from the OS
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -23,24 +23,29 @@ func gracefulShutdown(apiServer *http.Server) {
 	log.Println("Shutting down gracefully, press Ctrl+C again to force...")
 
 	// the context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	// the request it is currently handling and stop its background workers
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
 
 	log.Println("Server exiting...")
+	close(done)
 }
 
 func main() {
 	server := server.NewServer()
 
-	fmt.Printf("Server is running on port %s\n", server.Addr)
+	fmt.Printf("Server is running on port %s\n", server.Addr())
 
-	go gracefulShutdown(server)
+	done := make(chan struct{})
+	go gracefulShutdown(server, done)
 
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("HTTP server error: %s", err))
 	}
+
+	// wait for the shutdown to finish before exiting
+	<-done
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chi-mysql-boilerplate/internal/database"
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/filters"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/export"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+)
+
+// imports posts for a user straight into the database, bypassing the HTTP limits on file size
+// e.g. go run ./cmd/import -user 1 -file posts.csv -mode best-effort
+func main() {
+	userId := flag.Uint64("user", 0, "ID of the user the posts are imported for")
+	path := flag.String("file", "", "JSON or CSV file to import")
+	format := flag.String("format", "", "json or csv, guessed from the file extension when left out")
+	mode := flag.String("mode", httpcommon.ImportMode.Atomic, "atomic or best-effort")
+	flag.Parse()
+
+	if *userId == 0 || *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*path)), ".")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	// no row limit here, the limit only protects the API
+	rows, err := export.Decode(file, *format, int(^uint(0)>>1))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	// imported posts go through the same content filters as the API, the pipeline is only loaded
+	// once here since the import doesn't run long enough to need reloads
+	if err = filters.NewWatcher(httpcommon.FilterConstants.ConfigPath, httpcommon.FilterConstants.ReloadInterval).Load(); err != nil {
+		log.Fatal(err)
+	}
+
+	importService := services.NewImportService(db, validators.NewValidator(helpers.MessageLogs))
+	report, err := importService.Import(context.Background(), *userId, rows, *mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(out))
+
+	if report.Rejected > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(db *sql.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: services.NewAnalyticsService(db)}
+}
+
+// GET /users/me/analytics?from=YYYY-MM-DD&to=YYYY-MM-DD
+// both ends are included, the range defaults to the days leading up to today
+func (handler *AnalyticsHandler) GetMyAnalytics(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	from, to, ok := getDateRange(w, r)
+	if !ok {
+		return
+	}
+
+	analytics, err := handler.analyticsService.GetUserAnalytics(userId, from, to)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&analytics))
+}
+
+// helper function to read the from and to query parameters, writes a 400 and returns false if they're invalid
+func getDateRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	defaultSpan := httpcommon.ViewConstants.DefaultAnalyticsDays - 1
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			writeDateRangeError(w, "to", httpcommon.ErrorMessage.InvalidDateRange)
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -defaultSpan)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil || parsed.After(to) {
+			writeDateRangeError(w, "from", httpcommon.ErrorMessage.InvalidDateRange)
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	if from.AddDate(0, 0, httpcommon.ViewConstants.MaxAnalyticsDays).Before(to.AddDate(0, 0, 1)) {
+		writeDateRangeError(w, "from", httpcommon.ErrorMessage.DateRangeTooLong)
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+func writeDateRangeError(w http.ResponseWriter, field string, message string) {
+	helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+		httpcommon.Error{
+			Field:   field,
+			Message: message,
+			Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+		}))
+}
@@ -61,6 +61,13 @@ func (handler *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 					Code:    httpcommon.ErrorResponseCode.InvalidRequest,
 				}))
 			return
+		} else if err.Error() == httpcommon.ErrorMessage.AccountSuspended {
+			helpers.WriteJSON(w, http.StatusForbidden, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Message: err.Error(),
+					Code:    httpcommon.ErrorResponseCode.Unauthorized,
+				}))
+			return
 		} else {
 			// handle other errors
 			helpers.MessageLogs.ErrorLog.Println(err)
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+)
+
+type BookmarkHandler struct {
+	bookmarkService *services.BookmarkService
+	postService     *services.PostService
+}
+
+func NewBookmarkHandler(db *sql.DB) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkService: services.NewBookmarkService(db),
+		postService:     services.NewPostService(db),
+	}
+}
+
+// GET /users/me/bookmarks
+func (handler *BookmarkHandler) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := handler.bookmarkService.GetByUserId(userId, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&posts))
+}
+
+// PUT /posts/{id}/bookmark
+func (handler *BookmarkHandler) AddBookmark(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	// only posts the user can see may be bookmarked
+	if _, err := handler.postService.GetById(postId, userId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	if err := handler.bookmarkService.Add(userId, postId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Post bookmarked successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// DELETE /posts/{id}/bookmark
+func (handler *BookmarkHandler) RemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	if err := handler.bookmarkService.Remove(userId, postId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Bookmark removed successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
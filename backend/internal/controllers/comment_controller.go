@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"net/http"
+)
+
+type CommentHandler struct {
+	commentService *services.CommentService
+	postService    *services.PostService
+	validator      *validators.Validator
+}
+
+func NewCommentHandler(db *sql.DB, validator *validators.Validator) *CommentHandler {
+	return &CommentHandler{
+		commentService: services.NewCommentService(db),
+		postService:    services.NewPostService(db),
+		validator:      validator,
+	}
+}
+
+// GET /posts/{id}/comments
+func (handler *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// posts the viewer can't see have no comments to show either
+	if _, err := handler.postService.GetById(postId, GetViewerIdFromContext(r)); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	comments, err := handler.commentService.GetByPostId(postId, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&comments))
+}
+
+// POST /posts/{id}/comments
+func (handler *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	var req models.CommentRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	if _, err := handler.postService.GetById(postId, userId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	newComment, err := handler.commentService.Create(postId, userId, req)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&newComment))
+}
+
+// PUT /posts/{id}/comments/{commentId}
+func (handler *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	commentId := GetIdFromURLParam(w, r, "commentId")
+	if commentId == 0 {
+		return
+	}
+
+	var req models.CommentUpdateRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	if !handler.CanModifyComment(w, r, postId, commentId) {
+		return
+	}
+
+	if err := handler.commentService.UpdateById(commentId, req.Content); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Comment updated successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// DELETE /posts/{id}/comments/{commentId}
+func (handler *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	commentId := GetIdFromURLParam(w, r, "commentId")
+	if commentId == 0 {
+		return
+	}
+
+	if !handler.CanModifyComment(w, r, postId, commentId) {
+		return
+	}
+
+	if err := handler.commentService.DeleteById(commentId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Comment deleted successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// helper function to determine if the one updating or deleting the comment
+// is either its author or the author of the post
+func (handler *CommentHandler) CanModifyComment(w http.ResponseWriter, r *http.Request, postId uint64, commentId uint64) bool {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return false
+	}
+
+	canModify, err := handler.commentService.CanModify(postId, commentId, userId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return false
+	}
+
+	if !canModify {
+		helpers.WriteJSON(w, http.StatusForbidden, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: httpcommon.ErrorMessage.InvalidRequest,
+				Code:    httpcommon.ErrorResponseCode.Unauthorized,
+			}))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/export"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(db *sql.DB) *ExportHandler {
+	return &ExportHandler{exportService: services.NewExportService(db)}
+}
+
+// GET /users/me/posts/export?format=json|csv|md
+func (handler *ExportHandler) ExportMyPosts(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	handler.writeExport(w, r, userId, fmt.Sprintf("posts-user-%d", userId))
+}
+
+// GET /admin/posts/export?format=json|csv|md
+func (handler *ExportHandler) ExportAllPosts(w http.ResponseWriter, r *http.Request) {
+	handler.writeExport(w, r, 0, "posts")
+}
+
+// stream the posts straight from the database into the response in the requested format
+func (handler *ExportHandler) writeExport(w http.ResponseWriter, r *http.Request, userId uint64, fileName string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = httpcommon.ExportFormat.JSON
+	}
+
+	var encoder export.Encoder
+	var contentType string
+	switch format {
+	case httpcommon.ExportFormat.JSON:
+		encoder, contentType = export.NewJSONEncoder(w), "application/json"
+	case httpcommon.ExportFormat.CSV:
+		encoder, contentType = export.NewCSVEncoder(w), "text/csv; charset=utf-8"
+	case httpcommon.ExportFormat.Markdown:
+		encoder, contentType, format = export.NewMarkdownZipEncoder(w), "application/zip", "zip"
+	default:
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Field:   "format",
+				Message: httpcommon.ErrorMessage.InvalidExportFormat,
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return
+	}
+
+	// big exports take longer than the server's write timeout allows for regular responses
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, fileName, time.Now().UTC().Format("20060102"), format))
+	w.WriteHeader(http.StatusOK)
+
+	// once the first byte is out the status can't change anymore,
+	// so a failure halfway through can only cut the file short
+	if err := handler.exportService.StreamPosts(r.Context(), userId, encoder.Write); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return
+	}
+	if err := encoder.Close(); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+	}
+}
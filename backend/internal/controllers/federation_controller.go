@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/activitypub"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type FederationHandler struct {
+	federationService *services.FederationService
+	validator         *validators.Validator
+}
+
+func NewFederationHandler(db *sql.DB, validator *validators.Validator) *FederationHandler {
+	return &FederationHandler{federationService: services.NewFederationService(db), validator: validator}
+}
+
+// GET /.well-known/webfinger?resource=acct:{username}@{domain}
+func (handler *FederationHandler) WebFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: "Missing resource parameter",
+				Field:   "resource",
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return
+	}
+
+	jrd, err := handler.federationService.WebFinger(resource)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	writeActivityJSON(w, http.StatusOK, jrd, "application/jrd+json")
+}
+
+// GET /ap/users/{username}
+func (handler *FederationHandler) GetActor(w http.ResponseWriter, r *http.Request) {
+	actor, err := handler.federationService.GetActor(chi.URLParam(r, "username"))
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	writeActivityJSON(w, http.StatusOK, actor, activitypub.ContentType)
+}
+
+// GET /ap/users/{username}/outbox
+func (handler *FederationHandler) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	var page *models.PageRequest
+	if r.URL.Query().Has("page") {
+		pageRequest, ok := GetPageRequest(w, r)
+		if !ok {
+			return
+		}
+		pageRequest.Limit = min(pageRequest.Limit, httpcommon.FederationConstants.OutboxPageLimit)
+		page = &pageRequest
+	}
+
+	outbox, err := handler.federationService.GetOutbox(chi.URLParam(r, "username"), page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	writeActivityJSON(w, http.StatusOK, outbox, activitypub.ContentType)
+}
+
+// GET /ap/users/{username}/followers
+func (handler *FederationHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	followers, err := handler.federationService.GetFollowers(chi.URLParam(r, "username"))
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	writeActivityJSON(w, http.StatusOK, followers, activitypub.ContentType)
+}
+
+// GET /ap/posts/{id}
+func (handler *FederationHandler) GetNote(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	note, err := handler.federationService.GetNote(postId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	writeActivityJSON(w, http.StatusOK, note, activitypub.ContentType)
+}
+
+// POST /ap/inbox and POST /ap/users/{username}/inbox
+// both inboxes work the same way, activities say who they're meant for
+func (handler *FederationHandler) PostInbox(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, httpcommon.FederationConstants.MaxInboxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.WriteJSON(w, http.StatusRequestEntityTooLarge, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Message: httpcommon.ErrorMessage.InvalidActivity,
+					Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+				}))
+			return
+		}
+		WriteServiceError(w, err)
+		return
+	}
+
+	if err = handler.federationService.ReceiveActivity(r, body); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// PUT /federation/following
+func (handler *FederationHandler) FollowRemote(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	var req models.RemoteFollowRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	actor, err := handler.federationService.FollowRemote(userId, req.Handle)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&actor))
+}
+
+// DELETE /federation/following/{actorId}
+func (handler *FederationHandler) UnfollowRemote(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	actorId := GetIdFromURLParam(w, r, "actorId")
+	if actorId == 0 {
+		return
+	}
+
+	if err := handler.federationService.UnfollowRemote(userId, actorId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Remote user unfollowed successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// GET /federation/following
+func (handler *FederationHandler) GetRemoteFollowing(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	following, err := handler.federationService.GetRemoteFollowing(userId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&following))
+}
+
+// GET /federation/timeline
+func (handler *FederationHandler) GetRemoteTimeline(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := handler.federationService.GetRemoteTimeline(userId, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&posts))
+}
+
+// helper function to write a document as is, other servers don't expect our response envelope
+func writeActivityJSON(w http.ResponseWriter, statusCode int, data interface{}, contentType string) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	if _, err = w.Write(out); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+	}
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/feeds"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type FeedHandler struct {
+	postService *services.PostService
+	userService *services.UserService
+}
+
+func NewFeedHandler(db *sql.DB) *FeedHandler {
+	return &FeedHandler{postService: services.NewPostService(db), userService: services.NewUserService(db)}
+}
+
+// GET /feeds/posts.atom
+func (handler *FeedHandler) GetPostsAtom(w http.ResponseWriter, r *http.Request) {
+	handler.writeFeed(w, r, 0, httpcommon.FeedConstants.Title, httpcommon.FeedConstants.SiteURL, "atom")
+}
+
+// GET /feeds/posts.rss
+func (handler *FeedHandler) GetPostsRSS(w http.ResponseWriter, r *http.Request) {
+	handler.writeFeed(w, r, 0, httpcommon.FeedConstants.Title, httpcommon.FeedConstants.SiteURL, "rss")
+}
+
+// GET /feeds/users/{username}.atom
+// the route captures the whole file name, chi can't split "{username}.atom" when usernames contain dots
+func (handler *FeedHandler) GetUserAtom(w http.ResponseWriter, r *http.Request) {
+	username, ok := strings.CutSuffix(chi.URLParam(r, "file"), ".atom")
+	if !ok || username == "" {
+		helpers.WriteJSON(w, http.StatusNotFound, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: httpcommon.ErrorMessage.RecordNotFound,
+				Code:    httpcommon.ErrorResponseCode.RecordNotFound,
+			}))
+		return
+	}
+
+	userId, err := handler.userService.GetIdByUsername(username)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	title := fmt.Sprintf("%s - %s", username, httpcommon.FeedConstants.Title)
+	link := fmt.Sprintf("%s/users/%d", httpcommon.FeedConstants.SiteURL, userId)
+	handler.writeFeed(w, r, userId, title, link, "atom")
+}
+
+// build a feed out of a user's (or everyone's) latest public posts,
+// answering with 304 when the reader already has the current version
+func (handler *FeedHandler) writeFeed(w http.ResponseWriter, r *http.Request, userId uint64, title string, link string, format string) {
+	posts, err := handler.postService.GetFeed(userId, httpcommon.FeedConstants.ItemLimit)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	// built from configuration rather than the Host header, which the client picks and
+	// which would otherwise end up in responses shared through caches
+	selfLink := httpcommon.FeedConstants.SiteURL + r.URL.EscapedPath()
+	feed := feeds.Feed{
+		ID:       selfLink,
+		Title:    title,
+		Link:     link,
+		SelfLink: selfLink,
+		// an empty feed has never been updated, the epoch keeps that stable across requests
+		Updated: time.Unix(0, 0),
+		Entries: make([]feeds.Entry, len(posts)),
+	}
+	for i, post := range posts {
+		feed.Entries[i] = feedEntry(post)
+		if post.UpdatedAt.After(feed.Updated) {
+			feed.Updated = post.UpdatedAt
+		}
+	}
+
+	etag := feedETag(format, selfLink, posts)
+	lastModified := feed.Updated.UTC().Truncate(time.Second)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var out []byte
+	contentType := "application/atom+xml; charset=utf-8"
+	if format == "rss" {
+		out, err = feeds.RSS(feed)
+		contentType = "application/rss+xml; charset=utf-8"
+	} else {
+		out, err = feeds.Atom(feed)
+	}
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(out); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+	}
+}
+
+func feedEntry(post *models.PostResponse) feeds.Entry {
+	permalink := fmt.Sprintf("%s/posts/%d", httpcommon.FeedConstants.SiteURL, post.ID)
+	published := post.CreatedAt
+	if post.PublishAt != nil {
+		published = *post.PublishAt
+	}
+
+	return feeds.Entry{
+		ID:          permalink,
+		Title:       feeds.Title(post.Content),
+		Link:        permalink,
+		Author:      post.UserName,
+		ContentHTML: post.ContentHTML,
+		Published:   published,
+		Updated:     post.UpdatedAt,
+	}
+}
+
+// the tag changes whenever an entry is added, edited or removed, which Last-Modified alone
+// can't tell for removals
+func feedETag(format string, selfLink string, posts []*models.PostResponse) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", format, selfLink)
+	for _, post := range posts {
+		fmt.Fprintf(hash, "%d %d %d\n", post.ID, post.Version, post.UpdatedAt.Unix())
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// If-None-Match wins over If-Modified-Since when a reader sends both
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			// If-None-Match uses weak comparison
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !lastModified.After(ifModifiedSince)
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// helper function to grab a numeric ID from the URL parameters,
+// returns 0 (and writes the error response) if it's missing or malformed
+func GetIdFromURLParam(w http.ResponseWriter, r *http.Request, param string) uint64 {
+	value := chi.URLParam(r, param)
+	if value == "" {
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: "Missing " + param + " parameter",
+				Field:   param,
+				Code:    httpcommon.ErrorResponseCode.MissingIdParameter,
+			}))
+		return 0
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Field:   param,
+				Message: httpcommon.ErrorMessage.InvalidDataType,
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return 0
+	}
+
+	return id
+}
+
+// helper function to read the cursor and limit query parameters,
+// returns false (and writes the error response) if either is malformed
+func GetPageRequest(w http.ResponseWriter, r *http.Request) (models.PageRequest, bool) {
+	page := models.PageRequest{Limit: httpcommon.PaginationConstants.DefaultLimit}
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		value, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Field:   "cursor",
+					Message: httpcommon.ErrorMessage.InvalidDataType,
+					Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+				}))
+			return page, false
+		}
+		page.Cursor = value
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Field:   "limit",
+					Message: httpcommon.ErrorMessage.InvalidDataType,
+					Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+				}))
+			return page, false
+		}
+		page.Limit = min(value, httpcommon.PaginationConstants.MaxLimit)
+	}
+
+	return page, true
+}
+
+// helper function to build the ETag of a post from its version
+func PostETag(version uint64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// helper function to read the post versions a client expects from the If-Match header,
+// returns none when any version will do, and false (after writing the error response)
+// if the header is required but missing or lists a tag we couldn't have handed out
+func GetIfMatchVersions(w http.ResponseWriter, r *http.Request) ([]uint64, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		if httpcommon.ConcurrencyConstants.RequireIfMatch {
+			helpers.WriteJSON(w, http.StatusPreconditionRequired, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Message: httpcommon.ErrorMessage.MissingIfMatch,
+					Code:    httpcommon.ErrorResponseCode.MissingPrecondition,
+				}))
+			return nil, false
+		}
+		return nil, true
+	}
+	if ifMatch == "*" {
+		return nil, true
+	}
+
+	versions := []uint64{}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		// If-Match uses strong comparison, so weak tags never match
+		if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		tag, ok := strings.CutPrefix(candidate, `"`)
+		if ok {
+			tag, ok = strings.CutSuffix(tag, `"`)
+		}
+		version, err := strconv.ParseUint(tag, 10, 64)
+		if ok && err == nil && version != 0 {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		helpers.WriteJSON(w, http.StatusPreconditionFailed, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: httpcommon.ErrorMessage.VersionMismatch,
+				Code:    httpcommon.ErrorResponseCode.PreconditionFailed,
+			}))
+		return nil, false
+	}
+
+	return versions, true
+}
+
+// helper function to turn an error returned by a service into a response
+func WriteServiceError(w http.ResponseWriter, err error) {
+	helpers.MessageLogs.ErrorLog.Println(err)
+
+	if err.Error() == httpcommon.ErrorMessage.VersionMismatch {
+		helpers.WriteJSON(w, http.StatusPreconditionFailed, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: err.Error(),
+				Code:    httpcommon.ErrorResponseCode.PreconditionFailed,
+			}))
+		return
+	}
+
+	if err.Error() == httpcommon.ErrorMessage.InvalidSignature {
+		helpers.WriteJSON(w, http.StatusUnauthorized, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: err.Error(),
+				Code:    httpcommon.ErrorResponseCode.Unauthorized,
+			}))
+		return
+	}
+
+	if httpcommon.IsBadRequestMessage(err.Error()) {
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: err.Error(),
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		helpers.WriteJSON(w, http.StatusNotFound, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: httpcommon.ErrorMessage.RecordNotFound,
+				Code:    httpcommon.ErrorResponseCode.RecordNotFound,
+			}))
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
+		httpcommon.Error{
+			Message: err.Error(),
+			Code:    httpcommon.ErrorResponseCode.InternalServerError,
+		}))
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/export"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type ImportHandler struct {
+	importService *services.ImportService
+}
+
+func NewImportHandler(db *sql.DB, validator *validators.Validator) *ImportHandler {
+	return &ImportHandler{importService: services.NewImportService(db, validator)}
+}
+
+// POST /users/me/posts/import?format=json|csv&mode=atomic|best-effort
+// expects the file as the raw request body, responds with a report of what happened to each row
+func (handler *ImportHandler) ImportPosts(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = httpcommon.ExportFormat.JSON
+	}
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = httpcommon.ImportMode.Atomic
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, httpcommon.ImportConstants.MaxBytes)
+	rows, err := export.Decode(r.Body, format, httpcommon.ImportConstants.MaxRows)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.WriteJSON(w, http.StatusRequestEntityTooLarge, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Message: err.Error(),
+					Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+				}))
+			return
+		}
+		if !httpcommon.IsBadRequestMessage(err.Error()) {
+			// anything else the decoder trips over is a malformed file
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Message: err.Error(),
+					Code:    httpcommon.ErrorResponseCode.InvalidDataType,
+				}))
+			return
+		}
+		WriteServiceError(w, err)
+		return
+	}
+
+	// large imports take longer than the server's write timeout allows for regular responses
+	if err = http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+	}
+
+	report, err := handler.importService.Import(r.Context(), userId, rows, mode)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&report))
+}
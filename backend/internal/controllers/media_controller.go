@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/storage"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type MediaHandler struct {
+	mediaService *services.MediaService
+}
+
+func NewMediaHandler(db *sql.DB, storage storage.Storage) *MediaHandler {
+	return &MediaHandler{mediaService: services.NewMediaService(db, storage)}
+}
+
+// POST /media
+// expects a multipart form with the image in the "file" field
+func (handler *MediaHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	maxBytes := httpcommon.MediaConstants.MaxUploadBytes
+	// leave some room for the multipart boundaries and headers
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeMediaTooLarge(w)
+			return
+		}
+
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Field:   "file",
+				Message: err.Error(),
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		writeMediaTooLarge(w)
+		return
+	}
+
+	media, err := handler.mediaService.Upload(userId, data)
+	if err != nil {
+		switch err.Error() {
+		case httpcommon.ErrorMessage.UnsupportedMedia:
+			helpers.WriteJSON(w, http.StatusUnsupportedMediaType, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Field:   "file",
+					Message: err.Error(),
+					Code:    httpcommon.ErrorResponseCode.InvalidDataType,
+				}))
+		case httpcommon.ErrorMessage.MediaTooLarge:
+			writeMediaTooLarge(w)
+		default:
+			WriteServiceError(w, err)
+		}
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&media))
+}
+
+func writeMediaTooLarge(w http.ResponseWriter) {
+	helpers.WriteJSON(w, http.StatusRequestEntityTooLarge, httpcommon.NewErrorResponse(
+		httpcommon.Error{
+			Field:   "file",
+			Message: httpcommon.ErrorMessage.MediaTooLarge,
+			Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+		}))
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"net/http"
+	"strconv"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	validator           *validators.Validator
+}
+
+func NewNotificationHandler(db *sql.DB, validator *validators.Validator) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: services.NewNotificationService(db),
+		validator:           validator,
+	}
+}
+
+// GET /notifications?unread=true&cursor=123&limit=20
+func (handler *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	unreadOnly := false
+	if unread := r.URL.Query().Get("unread"); unread != "" {
+		value, err := strconv.ParseBool(unread)
+		if err != nil {
+			helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+				httpcommon.Error{
+					Field:   "unread",
+					Message: httpcommon.ErrorMessage.InvalidDataType,
+					Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+				}))
+			return
+		}
+		unreadOnly = value
+	}
+
+	notifications, err := handler.notificationService.GetByUserId(userId, unreadOnly, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&notifications))
+}
+
+// POST /notifications/{notificationId}/read
+func (handler *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	notificationId := GetIdFromURLParam(w, r, "notificationId")
+	if notificationId == 0 {
+		return
+	}
+
+	if err := handler.notificationService.MarkRead(userId, notificationId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Notification marked as read"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// POST /notifications/read-all
+func (handler *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	if err := handler.notificationService.MarkAllRead(userId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "All notifications marked as read"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// GET /notifications/preferences
+func (handler *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	preferences, err := handler.notificationService.GetPreferences(userId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&preferences))
+}
+
+// PUT /notifications/preferences
+func (handler *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	var req models.NotificationPreferencesRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	preferences, err := handler.notificationService.SetPreferences(userId, req.Muted)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&preferences))
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"net/http"
+)
+
+type PinHandler struct {
+	pinService  *services.PinService
+	postService *services.PostService
+	validator   *validators.Validator
+}
+
+func NewPinHandler(db *sql.DB, validator *validators.Validator) *PinHandler {
+	return &PinHandler{
+		pinService:  services.NewPinService(db),
+		postService: services.NewPostService(db),
+		validator:   validator,
+	}
+}
+
+// PUT /posts/{id}/pin
+func (handler *PinHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	// users can only pin their own posts
+	if !IsPostAuthor(w, r, handler.postService, postId) {
+		return
+	}
+
+	if err := handler.pinService.Pin(GetViewerIdFromContext(r), postId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Post pinned successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// DELETE /posts/{id}/pin
+func (handler *PinHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	if !IsPostAuthor(w, r, handler.postService, postId) {
+		return
+	}
+
+	if err := handler.pinService.Unpin(GetViewerIdFromContext(r), postId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Post unpinned successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// PUT /users/me/pins
+func (handler *PinHandler) ReorderPins(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+
+	var req models.PinOrderRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	posts, err := handler.pinService.Reorder(userId, req.PostIDs)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&posts))
+}
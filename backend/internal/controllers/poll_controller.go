@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"net/http"
+)
+
+type PollHandler struct {
+	pollService *services.PollService
+	postService *services.PostService
+	validator   *validators.Validator
+}
+
+func NewPollHandler(db *sql.DB, validator *validators.Validator) *PollHandler {
+	return &PollHandler{
+		pollService: services.NewPollService(db),
+		postService: services.NewPostService(db),
+		validator:   validator,
+	}
+}
+
+// POST /posts/{id}/poll/votes
+func (handler *PollHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	var req models.PollVoteRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	// only polls on posts the user can see may be voted in
+	if _, err := handler.postService.GetById(postId, userId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	poll, err := handler.pollService.Vote(postId, userId, req.OptionIDs)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&poll))
+}
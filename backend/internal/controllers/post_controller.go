@@ -7,6 +7,8 @@ import (
 	"chi-mysql-boilerplate/internal/utils/helpers"
 	"chi-mysql-boilerplate/internal/utils/validators"
 	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 
@@ -14,12 +16,13 @@ import (
 )
 
 type PostHandler struct {
-	postService *services.PostService
-	validator   *validators.Validator
+	postService  *services.PostService
+	viewRecorder *services.ViewRecorder
+	validator    *validators.Validator
 }
 
-func NewPostHandler(db *sql.DB, validator *validators.Validator) *PostHandler {
-	return &PostHandler{postService: services.NewPostService(db), validator: validator}
+func NewPostHandler(db *sql.DB, viewRecorder *services.ViewRecorder, validator *validators.Validator) *PostHandler {
+	return &PostHandler{postService: services.NewPostService(db), viewRecorder: viewRecorder, validator: validator}
 }
 
 // POST /posts
@@ -31,14 +34,9 @@ func (handler *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newPost, err := handler.postService.Create(userId, req.Content)
+	newPost, err := handler.postService.Create(userId, req)
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
-			httpcommon.Error{
-				Message: err.Error(),
-				Code:    httpcommon.ErrorResponseCode.InternalServerError,
-			}))
+		WriteServiceError(w, err)
 		return
 	}
 
@@ -47,7 +45,7 @@ func (handler *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 // GET /posts
 func (handler *PostHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	posts, err := handler.postService.GetAll()
+	posts, err := handler.postService.GetAll(GetViewerIdFromContext(r))
 	if err != nil {
 		helpers.MessageLogs.ErrorLog.Println(err)
 		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
@@ -78,7 +76,7 @@ func (handler *PostHandler) GetPostsByUserId(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	posts, err := handler.postService.GetByUserId(uint64(userId))
+	posts, err := handler.postService.GetByUserId(uint64(userId), GetViewerIdFromContext(r))
 	if err != nil {
 		helpers.MessageLogs.ErrorLog.Println(err)
 		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
@@ -109,18 +107,25 @@ func (handler *PostHandler) GetPostById(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	post, err := handler.postService.GetById(uint64(postId))
+	// missing posts and ones the viewer isn't allowed to see both come back as 404
+	viewerId := GetViewerIdFromContext(r)
+	post, err := handler.postService.GetById(uint64(postId), viewerId)
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
-			httpcommon.Error{
-				Message: err.Error(),
-				Code:    httpcommon.ErrorResponseCode.InternalServerError,
-			}))
+		WriteServiceError(w, err)
 		return
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&post))
+	// authors reading their own posts aren't counted
+	if viewerId == 0 {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		handler.viewRecorder.Record(post.ID, "ip:"+host)
+	} else if viewerId != post.UserID {
+		handler.viewRecorder.Record(post.ID, fmt.Sprintf("user:%d", viewerId))
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&post), http.Header{
+		"ETag": []string{PostETag(post.Version)},
+	})
 }
 
 // PUT /posts/{id}
@@ -155,23 +160,28 @@ func (handler *PostHandler) UpdatePostById(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// check if the one sending the request is the author of the post
-	if !handler.IsPostAuthor(w, r, uint64(postId)) {
+	// check if the one sending the request is the author of the post,
+	// missing and foreign posts are reported as such before any precondition
+	if !IsPostAuthor(w, r, handler.postService, uint64(postId)) {
 		return
 	}
 
-	if err = handler.postService.UpdateById(uint64(postId), req.Content); err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
-			httpcommon.Error{
-				Message: err.Error(),
-				Code:    httpcommon.ErrorResponseCode.InternalServerError,
-			}))
+	expectedVersions, ok := GetIfMatchVersions(w, r)
+	if !ok {
 		return
 	}
 
+	version, err := handler.postService.UpdateById(uint64(postId), req, expectedVersions)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	// hand back the new ETag so the client can keep editing without refetching
 	message := "Post updated successfully"
-	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message), http.Header{
+		"ETag": []string{PostETag(version)},
+	})
 }
 
 // DELETE /posts/{id}
@@ -199,18 +209,19 @@ func (handler *PostHandler) DeletePostById(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// check if the one sending the request is the author of the post
-	if !handler.IsPostAuthor(w, r, uint64(postId)) {
+	// check if the one sending the request is the author of the post,
+	// missing and foreign posts are reported as such before any precondition
+	if !IsPostAuthor(w, r, handler.postService, uint64(postId)) {
 		return
 	}
 
-	if err = handler.postService.DeleteById(uint64(postId)); err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
-			httpcommon.Error{
-				Message: err.Error(),
-				Code:    httpcommon.ErrorResponseCode.InternalServerError,
-			}))
+	expectedVersions, ok := GetIfMatchVersions(w, r)
+	if !ok {
+		return
+	}
+
+	if err = handler.postService.DeleteById(uint64(postId), expectedVersions); err != nil {
+		WriteServiceError(w, err)
 		return
 	}
 
@@ -218,6 +229,26 @@ func (handler *PostHandler) DeletePostById(w http.ResponseWriter, r *http.Reques
 	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
 }
 
+// GET /timeline
+func (handler *PostHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := handler.postService.GetTimeline(userId, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&posts))
+}
+
 // helper function to grab the user ID from a HTTP context
 func GetUserIdFromContext(w http.ResponseWriter, r *http.Request) uint64 {
 	id, ok := r.Context().Value(httpcommon.ContextKeyConstants.UserId).(uint64)
@@ -234,19 +265,24 @@ func GetUserIdFromContext(w http.ResponseWriter, r *http.Request) uint64 {
 	return id
 }
 
+// helper function to grab the user ID from a HTTP context on routes where logging in is optional,
+// returns 0 for anonymous viewers
+func GetViewerIdFromContext(r *http.Request) uint64 {
+	id, _ := r.Context().Value(httpcommon.ContextKeyConstants.UserId).(uint64)
+	return id
+}
+
 // helper function to determine if the one updating or deleting the post is its author
-func (handler *PostHandler) IsPostAuthor(w http.ResponseWriter, r *http.Request, postId uint64) bool {
+func IsPostAuthor(w http.ResponseWriter, r *http.Request, postService *services.PostService, postId uint64) bool {
 	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return false
+	}
 
 	// fetch the post for the author's ID
-	post, err := handler.postService.GetById(uint64(postId))
+	post, err := postService.GetById(postId, userId)
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
-			httpcommon.Error{
-				Message: err.Error(),
-				Code:    httpcommon.ErrorResponseCode.InternalServerError,
-			}))
+		WriteServiceError(w, err)
 		return false
 	}
 
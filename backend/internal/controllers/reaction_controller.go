@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ReactionHandler struct {
+	reactionService *services.ReactionService
+	postService     *services.PostService
+}
+
+func NewReactionHandler(db *sql.DB) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: services.NewReactionService(db),
+		postService:     services.NewPostService(db),
+	}
+}
+
+// GET /posts/{id}/reactions
+func (handler *ReactionHandler) GetReactors(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// posts the viewer can't see have no reactions to show either
+	if _, err := handler.postService.GetById(postId, GetViewerIdFromContext(r)); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	reactors, err := handler.reactionService.GetReactors(postId, r.URL.Query().Get("emoji"), page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&reactors))
+}
+
+// PUT /posts/{id}/reactions/{emoji}
+func (handler *ReactionHandler) React(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	if _, err := handler.postService.GetById(postId, userId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	if err := handler.reactionService.React(postId, userId, getEmojiFromURLParam(r)); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Reaction added successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// DELETE /posts/{id}/reactions/{emoji}
+func (handler *ReactionHandler) Unreact(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	if err := handler.reactionService.Unreact(postId, userId, getEmojiFromURLParam(r)); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Reaction removed successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// chi hands back the raw path segment when the request used a non-canonical encoding
+func getEmojiFromURLParam(r *http.Request) string {
+	emoji := chi.URLParam(r, "emoji")
+	if unescaped, err := url.PathUnescape(emoji); err == nil {
+		return unescaped
+	}
+	return emoji
+}
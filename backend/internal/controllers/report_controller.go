@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"net/http"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+	postService   *services.PostService
+	validator     *validators.Validator
+}
+
+func NewReportHandler(db *sql.DB, validator *validators.Validator) *ReportHandler {
+	return &ReportHandler{
+		reportService: services.NewReportService(db),
+		postService:   services.NewPostService(db),
+		validator:     validator,
+	}
+}
+
+// POST /posts/{id}/reports
+func (handler *ReportHandler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	var req models.ReportRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	// posts the user can't see can't be reported either
+	if _, err := handler.postService.GetById(postId, userId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	report, err := handler.reportService.Create(postId, userId, req)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&report))
+}
+
+// GET /moderation/reports?status=open|claimed|resolved
+func (handler *ReportHandler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != httpcommon.ReportStatus.Open && status != httpcommon.ReportStatus.Claimed && status != httpcommon.ReportStatus.Resolved {
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Field:   "status",
+				Message: httpcommon.ErrorMessage.InvalidReportStatus,
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return
+	}
+
+	reports, err := handler.reportService.GetQueue(status, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&reports))
+}
+
+// POST /moderation/reports/{reportId}/claim
+func (handler *ReportHandler) ClaimReport(w http.ResponseWriter, r *http.Request) {
+	moderatorId := GetUserIdFromContext(w, r)
+	if moderatorId == 0 {
+		return
+	}
+	reportId := GetIdFromURLParam(w, r, "reportId")
+	if reportId == 0 {
+		return
+	}
+
+	report, err := handler.reportService.Claim(reportId, moderatorId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&report))
+}
+
+// POST /moderation/reports/{reportId}/resolve
+func (handler *ReportHandler) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	moderatorId := GetUserIdFromContext(w, r)
+	if moderatorId == 0 {
+		return
+	}
+	reportId := GetIdFromURLParam(w, r, "reportId")
+	if reportId == 0 {
+		return
+	}
+
+	var req models.ResolveReportRequest
+	if err := handler.validator.BindJSONAndValidate(w, r, &req); err != nil {
+		// error is already handled in the validator
+		return
+	}
+
+	report, err := handler.reportService.Resolve(reportId, moderatorId, req)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&report))
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+)
+
+type RepostHandler struct {
+	repostService *services.RepostService
+}
+
+func NewRepostHandler(db *sql.DB) *RepostHandler {
+	return &RepostHandler{repostService: services.NewRepostService(db)}
+}
+
+// PUT /posts/{id}/repost
+func (handler *RepostHandler) Repost(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	if err := handler.repostService.Repost(userId, postId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Post reposted successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// DELETE /posts/{id}/repost
+func (handler *RepostHandler) Unrepost(w http.ResponseWriter, r *http.Request) {
+	userId := GetUserIdFromContext(w, r)
+	if userId == 0 {
+		return
+	}
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	if err := handler.repostService.Unrepost(userId, postId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Repost removed successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
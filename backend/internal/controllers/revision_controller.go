@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"database/sql"
+	"net/http"
+)
+
+type RevisionHandler struct {
+	revisionService *services.RevisionService
+	postService     *services.PostService
+	validator       *validators.Validator
+}
+
+func NewRevisionHandler(db *sql.DB, validator *validators.Validator) *RevisionHandler {
+	return &RevisionHandler{
+		revisionService: services.NewRevisionService(db),
+		postService:     services.NewPostService(db),
+		validator:       validator,
+	}
+}
+
+// GET /posts/{id}/revisions
+func (handler *RevisionHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+
+	// make sure the post exists and is visible so a missing post isn't reported as an empty history
+	if _, err := handler.postService.GetById(postId, GetViewerIdFromContext(r)); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	revisions, err := handler.revisionService.GetByPostId(postId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&revisions))
+}
+
+// GET /posts/{id}/revisions/{revisionId}
+func (handler *RevisionHandler) GetRevisionById(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	revisionId := GetIdFromURLParam(w, r, "revisionId")
+	if revisionId == 0 {
+		return
+	}
+
+	if _, err := handler.postService.GetById(postId, GetViewerIdFromContext(r)); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	revision, err := handler.revisionService.GetById(postId, revisionId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&revision))
+}
+
+// POST /posts/{id}/revisions/{revisionId}/revert
+func (handler *RevisionHandler) RevertToRevision(w http.ResponseWriter, r *http.Request) {
+	postId := GetIdFromURLParam(w, r, "id")
+	if postId == 0 {
+		return
+	}
+	revisionId := GetIdFromURLParam(w, r, "revisionId")
+	if revisionId == 0 {
+		return
+	}
+
+	// only the author may revert their post
+	if !IsPostAuthor(w, r, handler.postService, postId) {
+		return
+	}
+
+	// a revert overwrites the content like any edit, so it's held to the same version check
+	expectedVersions, ok := GetIfMatchVersions(w, r)
+	if !ok {
+		return
+	}
+
+	revision, err := handler.revisionService.GetById(postId, revisionId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	// old content was written under the limits of its day, it has to meet today's to come back
+	req := models.PostRequest{Content: revision.Content}
+	if errs := handler.validator.ValidateStruct(&req); errs != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(errs...))
+		return
+	}
+
+	// reverting is just another edit, so the current content gets archived as a revision too
+	version, err := handler.postService.UpdateById(postId, req, expectedVersions)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "Post reverted successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message), http.Header{
+		"ETag": []string{PostETag(version)},
+	})
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TagHandler struct {
+	tagService  *services.TagService
+	postService *services.PostService
+}
+
+func NewTagHandler(db *sql.DB) *TagHandler {
+	return &TagHandler{tagService: services.NewTagService(db), postService: services.NewPostService(db)}
+}
+
+// GET /tags?prefix=
+func (handler *TagHandler) SearchTags(w http.ResponseWriter, r *http.Request) {
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := handler.tagService.Search(r.URL.Query().Get("prefix"), page.Limit)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&tags))
+}
+
+// GET /tags/{tag}/posts
+func (handler *TagHandler) GetPostsByTag(w http.ResponseWriter, r *http.Request) {
+	tag, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil || tag == "" {
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Field:   "tag",
+				Message: httpcommon.ErrorMessage.InvalidRequest,
+				Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+			}))
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := handler.postService.GetByTag(tag, GetViewerIdFromContext(r), page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&posts))
+}
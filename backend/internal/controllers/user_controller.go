@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+)
+
+type UserHandler struct {
+	userService *services.UserService
+	pinService  *services.PinService
+}
+
+func NewUserHandler(db *sql.DB) *UserHandler {
+	return &UserHandler{userService: services.NewUserService(db), pinService: services.NewPinService(db)}
+}
+
+// GET /users/{userId}
+func (handler *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userId := GetIdFromURLParam(w, r, "userId")
+	if userId == 0 {
+		return
+	}
+
+	viewerId := GetViewerIdFromContext(r)
+	profile, err := handler.userService.GetProfile(userId, viewerId)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+	if profile.PinnedPosts, err = handler.pinService.GetPinned(userId, viewerId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&profile))
+}
+
+// GET /users/{userId}/followers
+func (handler *UserHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	userId := GetIdFromURLParam(w, r, "userId")
+	if userId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	followers, err := handler.userService.GetFollowers(userId, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&followers))
+}
+
+// GET /users/{userId}/following
+func (handler *UserHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userId := GetIdFromURLParam(w, r, "userId")
+	if userId == 0 {
+		return
+	}
+	page, ok := GetPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	following, err := handler.userService.GetFollowing(userId, page)
+	if err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&following))
+}
+
+// PUT /users/{userId}/follow
+func (handler *UserHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	followerId := GetUserIdFromContext(w, r)
+	if followerId == 0 {
+		return
+	}
+	followeeId := GetIdFromURLParam(w, r, "userId")
+	if followeeId == 0 {
+		return
+	}
+
+	if err := handler.userService.Follow(followerId, followeeId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "User followed successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
+
+// DELETE /users/{userId}/follow
+func (handler *UserHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	followerId := GetUserIdFromContext(w, r)
+	if followerId == 0 {
+		return
+	}
+	followeeId := GetIdFromURLParam(w, r, "userId")
+	if followeeId == 0 {
+		return
+	}
+
+	if err := handler.userService.Unfollow(followerId, followeeId); err != nil {
+		WriteServiceError(w, err)
+		return
+	}
+
+	message := "User unfollowed successfully"
+	helpers.WriteJSON(w, http.StatusOK, httpcommon.NewSuccessResponse(&message))
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `comments`;
\ No newline at end of file
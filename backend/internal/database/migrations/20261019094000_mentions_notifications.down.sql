@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `notifications`;
+
+DROP TABLE IF EXISTS `post_mentions`;
\ No newline at end of file
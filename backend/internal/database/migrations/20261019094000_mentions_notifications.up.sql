@@ -0,0 +1,26 @@
+CREATE TABLE post_mentions (
+    post_id INT UNSIGNED NOT NULL,
+    user_id INT UNSIGNED NOT NULL,
+    start_offset INT UNSIGNED NOT NULL,
+    end_offset INT UNSIGNED NOT NULL,
+    PRIMARY KEY (post_id, start_offset),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_post_mentions_user_id (user_id)
+);
+
+CREATE TABLE notifications (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    actor_id INT UNSIGNED NOT NULL,
+    post_id INT UNSIGNED,
+    comment_id INT UNSIGNED,
+    read_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    INDEX idx_notifications_user_id_id (user_id, id)
+);
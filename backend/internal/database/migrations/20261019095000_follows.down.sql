@@ -0,0 +1,3 @@
+DROP INDEX idx_posts_user_id_id ON posts;
+
+DROP TABLE IF EXISTS `follows`;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE follows (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    follower_id INT UNSIGNED NOT NULL,
+    followee_id INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_follows_follower_followee (follower_id, followee_id),
+    INDEX idx_follows_followee_id_follower_id (followee_id, follower_id)
+);
+
+-- the timeline and per-user listings filter posts by author and page through them by ID
+CREATE INDEX idx_posts_user_id_id ON posts (user_id, id);
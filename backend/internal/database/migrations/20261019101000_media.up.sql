@@ -0,0 +1,24 @@
+CREATE TABLE media (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    thumbnail_key VARCHAR(255) NOT NULL,
+    url VARCHAR(1024) NOT NULL,
+    thumbnail_url VARCHAR(1024) NOT NULL,
+    mime_type VARCHAR(64) NOT NULL,
+    width INT UNSIGNED NOT NULL,
+    height INT UNSIGNED NOT NULL,
+    size_bytes INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE post_media (
+    post_id INT UNSIGNED NOT NULL,
+    media_id INT UNSIGNED NOT NULL,
+    position TINYINT UNSIGNED NOT NULL,
+    alt_text VARCHAR(1000) CHARACTER SET utf8mb4 NOT NULL DEFAULT '',
+    PRIMARY KEY (post_id, media_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- rendered from the Markdown source in content, posts written before this column existed
+-- are rendered on the fly until they're edited
+ALTER TABLE posts ADD COLUMN content_html MEDIUMTEXT NULL AFTER content;
@@ -0,0 +1,5 @@
+DROP INDEX idx_posts_status_publish_at ON posts;
+
+ALTER TABLE posts
+    DROP COLUMN publish_at,
+    DROP COLUMN status;
\ No newline at end of file
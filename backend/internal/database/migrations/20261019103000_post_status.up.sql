@@ -0,0 +1,8 @@
+-- publish_at is when a scheduled post goes out, or when a published post went out
+ALTER TABLE posts
+    ADD COLUMN status ENUM('draft', 'scheduled', 'published') NOT NULL DEFAULT 'published',
+    ADD COLUMN publish_at TIMESTAMP NULL DEFAULT NULL;
+
+UPDATE posts SET publish_at = created_at, updated_at = updated_at;
+
+CREATE INDEX idx_posts_status_publish_at ON posts (status, publish_at);
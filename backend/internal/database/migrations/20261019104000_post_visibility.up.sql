@@ -0,0 +1,2 @@
+ALTER TABLE posts
+    ADD COLUMN visibility ENUM('public', 'followers', 'private') NOT NULL DEFAULT 'public';
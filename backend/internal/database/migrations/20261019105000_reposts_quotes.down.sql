@@ -0,0 +1,7 @@
+ALTER TABLE posts
+    DROP FOREIGN KEY fk_posts_repost_of_id,
+    DROP INDEX uq_posts_user_repost,
+    DROP INDEX idx_posts_repost_of_id,
+    DROP INDEX idx_posts_quote_of_id,
+    DROP COLUMN repost_of_id,
+    DROP COLUMN quote_of_id;
@@ -0,0 +1,9 @@
+-- reposts go away with the post they share, while quote posts outlive the post they quote
+-- so quote_of_id has no foreign key and may point at a post that no longer exists
+ALTER TABLE posts
+    ADD COLUMN repost_of_id INT UNSIGNED NULL DEFAULT NULL,
+    ADD COLUMN quote_of_id INT UNSIGNED NULL DEFAULT NULL,
+    ADD CONSTRAINT fk_posts_repost_of_id FOREIGN KEY (repost_of_id) REFERENCES posts(id) ON DELETE CASCADE,
+    ADD UNIQUE KEY uq_posts_user_repost (user_id, repost_of_id),
+    ADD INDEX idx_posts_repost_of_id (repost_of_id),
+    ADD INDEX idx_posts_quote_of_id (quote_of_id);
@@ -0,0 +1,3 @@
+-- bumped by every edit, clients send it back through If-Match to detect lost updates
+ALTER TABLE posts
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
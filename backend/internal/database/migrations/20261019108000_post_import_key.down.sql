@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP INDEX uq_posts_user_import_key,
+    DROP COLUMN import_key;
@@ -0,0 +1,4 @@
+-- lets re-running an import skip the posts it already created
+ALTER TABLE posts
+    ADD COLUMN import_key VARCHAR(191) NULL DEFAULT NULL,
+    ADD UNIQUE KEY uq_posts_user_import_key (user_id, import_key);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS delivery_jobs;
+DROP TABLE IF EXISTS remote_posts;
+DROP TABLE IF EXISTS remote_following;
+DROP TABLE IF EXISTS remote_followers;
+DROP TABLE IF EXISTS remote_actors;
+DROP TABLE IF EXISTS actor_keys;
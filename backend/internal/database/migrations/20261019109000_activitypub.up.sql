@@ -0,0 +1,76 @@
+-- signing keys of local users, created the first time a user is federated
+CREATE TABLE actor_keys (
+    user_id INT UNSIGNED PRIMARY KEY,
+    public_key_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- actors on other instances, cached from their actor documents
+CREATE TABLE remote_actors (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    actor_uri VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    inbox_uri VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    shared_inbox_uri VARCHAR(512) CHARACTER SET ascii NULL DEFAULT NULL,
+    key_id VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    public_key_pem TEXT NOT NULL,
+    fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_remote_actors_actor_uri (actor_uri),
+    INDEX idx_remote_actors_key_id (key_id)
+);
+
+-- remote actors following local users
+CREATE TABLE remote_followers (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    remote_actor_id INT UNSIGNED NOT NULL,
+    follow_activity_uri VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (remote_actor_id) REFERENCES remote_actors(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_remote_followers_user_actor (user_id, remote_actor_id)
+);
+
+-- local users following remote actors, accepted once the remote instance sends an Accept
+CREATE TABLE remote_following (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    remote_actor_id INT UNSIGNED NOT NULL,
+    follow_activity_uri VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (remote_actor_id) REFERENCES remote_actors(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_remote_following_user_actor (user_id, remote_actor_id),
+    UNIQUE KEY uq_remote_following_follow_activity_uri (follow_activity_uri)
+);
+
+-- notes received from remote actors that local users follow
+CREATE TABLE remote_posts (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    remote_actor_id INT UNSIGNED NOT NULL,
+    object_uri VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    content_html MEDIUMTEXT NOT NULL,
+    url VARCHAR(512) NULL DEFAULT NULL,
+    published_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (remote_actor_id) REFERENCES remote_actors(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_remote_posts_object_uri (object_uri)
+);
+
+-- outgoing activities waiting to be delivered, one row per inbox, deleted once delivered
+CREATE TABLE delivery_jobs (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    inbox_uri VARCHAR(512) CHARACTER SET ascii NOT NULL,
+    activity MEDIUMTEXT NOT NULL,
+    status ENUM('pending', 'failed') NOT NULL DEFAULT 'pending',
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_delivery_jobs_status_next_attempt_at (status, next_attempt_at)
+);
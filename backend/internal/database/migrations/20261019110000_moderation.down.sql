@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE posts
+    DROP COLUMN hidden_at;
+
+UPDATE users SET role = 'user' WHERE role = 'moderator';
+
+ALTER TABLE users
+    DROP COLUMN suspended_at,
+    MODIFY COLUMN role ENUM('user', 'admin') NOT NULL DEFAULT 'user';
@@ -0,0 +1,30 @@
+ALTER TABLE users
+    MODIFY COLUMN role ENUM('user', 'moderator', 'admin') NOT NULL DEFAULT 'user',
+    ADD COLUMN suspended_at TIMESTAMP NULL DEFAULT NULL;
+
+-- hidden posts stay visible to their author only
+ALTER TABLE posts
+    ADD COLUMN hidden_at TIMESTAMP NULL DEFAULT NULL;
+
+CREATE TABLE reports (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    -- kept when the post is deleted so the report still shows what was done about it
+    post_id INT UNSIGNED NULL,
+    author_id INT UNSIGNED NOT NULL,
+    reporter_id INT UNSIGNED NOT NULL,
+    reason ENUM('spam', 'harassment', 'hate', 'violence', 'nudity', 'misinformation', 'other') NOT NULL,
+    details VARCHAR(1000) NULL DEFAULT NULL,
+    status ENUM('open', 'claimed', 'resolved') NOT NULL DEFAULT 'open',
+    moderator_id INT UNSIGNED NULL DEFAULT NULL,
+    action ENUM('dismiss', 'hide_post', 'delete_post', 'suspend_author') NULL DEFAULT NULL,
+    resolution_note VARCHAR(1000) NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    claimed_at TIMESTAMP NULL DEFAULT NULL,
+    resolved_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE SET NULL,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (moderator_id) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_reports_post_reporter (post_id, reporter_id),
+    INDEX idx_reports_status_id (status, id)
+);
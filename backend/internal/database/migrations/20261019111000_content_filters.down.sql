@@ -0,0 +1,13 @@
+DELETE FROM reports WHERE reporter_id IS NULL;
+
+ALTER TABLE reports
+    DROP COLUMN filter,
+    MODIFY COLUMN reporter_id INT UNSIGNED NOT NULL;
+
+UPDATE posts SET status = 'draft', updated_at = updated_at WHERE status = 'held';
+
+ALTER TABLE posts
+    MODIFY COLUMN status ENUM('draft', 'scheduled', 'published') NOT NULL DEFAULT 'published';
+
+ALTER TABLE users
+    DROP COLUMN created_at;
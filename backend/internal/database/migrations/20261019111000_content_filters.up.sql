@@ -0,0 +1,14 @@
+-- accounts from before sign-up times were recorded are dated by their first post
+ALTER TABLE users
+    ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE users
+SET created_at = COALESCE((SELECT MIN(posts.created_at) FROM posts WHERE posts.user_id = users.id), created_at);
+
+ALTER TABLE posts
+    MODIFY COLUMN status ENUM('draft', 'scheduled', 'published', 'held') NOT NULL DEFAULT 'published';
+
+-- reports raised by a content filter have no reporter
+ALTER TABLE reports
+    MODIFY COLUMN reporter_id INT UNSIGNED NULL,
+    ADD COLUMN filter VARCHAR(64) NULL DEFAULT NULL;
@@ -0,0 +1,28 @@
+-- one row per URL, shared by every post linking to it
+CREATE TABLE link_previews (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    -- SHA-256 of the URL, which is too long to be a unique key itself
+    url_hash BINARY(32) NOT NULL,
+    status ENUM('pending', 'ready', 'failed') NOT NULL DEFAULT 'pending',
+    title VARCHAR(300) CHARACTER SET utf8mb4 NULL DEFAULT NULL,
+    description VARCHAR(1000) CHARACTER SET utf8mb4 NULL DEFAULT NULL,
+    image_url VARCHAR(2048) NULL DEFAULT NULL,
+    site_name VARCHAR(200) CHARACTER SET utf8mb4 NULL DEFAULT NULL,
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    -- null while there's nothing to fetch
+    next_attempt_at TIMESTAMP NULL DEFAULT NULL,
+    fetched_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_link_previews_url_hash (url_hash),
+    INDEX idx_link_previews_next_attempt_at (next_attempt_at)
+);
+
+CREATE TABLE post_links (
+    post_id INT UNSIGNED NOT NULL,
+    link_preview_id INT UNSIGNED NOT NULL,
+    position TINYINT UNSIGNED NOT NULL,
+    PRIMARY KEY (post_id, link_preview_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (link_preview_id) REFERENCES link_previews(id) ON DELETE CASCADE
+);
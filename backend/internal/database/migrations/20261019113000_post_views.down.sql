@@ -0,0 +1,7 @@
+DROP INDEX idx_follows_followee_id_created_at ON follows;
+DROP INDEX idx_post_reactions_post_id_created_at ON post_reactions;
+
+DROP TABLE IF EXISTS post_view_days;
+
+ALTER TABLE posts
+    DROP COLUMN view_count;
@@ -0,0 +1,15 @@
+ALTER TABLE posts
+    ADD COLUMN view_count INT UNSIGNED NOT NULL DEFAULT 0;
+
+-- views per post per day (UTC), for author analytics
+CREATE TABLE post_view_days (
+    post_id INT UNSIGNED NOT NULL,
+    day DATE NOT NULL,
+    views INT UNSIGNED NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, day),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- analytics count reactions and new followers per day
+CREATE INDEX idx_post_reactions_post_id_created_at ON post_reactions (post_id, created_at);
+CREATE INDEX idx_follows_followee_id_created_at ON follows (followee_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP INDEX uq_posts_user_pinned_position,
+    DROP COLUMN pinned_position;
@@ -0,0 +1,4 @@
+-- 1 for the post shown first, null for posts that aren't pinned
+ALTER TABLE posts
+    ADD COLUMN pinned_position TINYINT UNSIGNED NULL DEFAULT NULL,
+    ADD UNIQUE KEY uq_posts_user_pinned_position (user_id, pinned_position);
@@ -0,0 +1,40 @@
+CREATE TABLE polls (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    post_id INT UNSIGNED NOT NULL UNIQUE,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    -- tallies stay hidden from voters until they've voted or the poll has closed
+    hide_results BOOLEAN NOT NULL DEFAULT FALSE,
+    closes_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_options (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    poll_id INT UNSIGNED NOT NULL,
+    position TINYINT UNSIGNED NOT NULL,
+    text VARCHAR(100) CHARACTER SET utf8mb4 NOT NULL,
+    FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_poll_options_poll_position (poll_id, position)
+);
+
+-- one row per voter, the primary key is what keeps anyone from voting twice
+CREATE TABLE poll_votes (
+    poll_id INT UNSIGNED NOT NULL,
+    user_id INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (poll_id, user_id),
+    FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- the options each vote picked, more than one for multiple choice polls
+CREATE TABLE poll_vote_options (
+    poll_id INT UNSIGNED NOT NULL,
+    user_id INT UNSIGNED NOT NULL,
+    option_id INT UNSIGNED NOT NULL,
+    PRIMARY KEY (poll_id, user_id, option_id),
+    FOREIGN KEY (poll_id, user_id) REFERENCES poll_votes(poll_id, user_id) ON DELETE CASCADE,
+    FOREIGN KEY (option_id) REFERENCES poll_options(id) ON DELETE CASCADE,
+    INDEX idx_poll_vote_options_option_id (option_id)
+);
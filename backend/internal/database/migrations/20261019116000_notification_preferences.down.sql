@@ -0,0 +1,5 @@
+DROP INDEX idx_notifications_user_id_kind_post_id ON notifications;
+
+DROP TABLE IF EXISTS notification_mutes;
+
+UPDATE notifications SET kind = 'report_resolved' WHERE kind = 'moderation';
@@ -0,0 +1,12 @@
+UPDATE notifications SET kind = 'moderation' WHERE kind = 'report_resolved';
+
+-- kinds of notifications a user doesn't want, they aren't created for them at all
+CREATE TABLE notification_mutes (
+    user_id INT UNSIGNED NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    PRIMARY KEY (user_id, kind),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- marking a group read and skipping repeated notifications look up a user's notifications by kind and post
+CREATE INDEX idx_notifications_user_id_kind_post_id ON notifications (user_id, kind, post_id);
@@ -2,9 +2,38 @@ package http_common
 
 import (
 	"os"
+	"strconv"
 	"time"
+
+	_ "github.com/joho/godotenv/autoload"
 )
 
+// helper function to read an integer setting from the environment, falling back to a default
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// helper function to read a string setting from the environment, falling back to a default
+func getEnvString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// helper function to read a boolean setting from the environment, falling back to a default
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
 type errorResponseCode struct {
 	InvalidRequest      string
 	InternalServerError string
@@ -12,6 +41,8 @@ type errorResponseCode struct {
 	MissingIdParameter  string
 	InvalidDataType     string
 	Unauthorized        string
+	PreconditionFailed  string
+	MissingPrecondition string
 }
 
 var ErrorResponseCode = errorResponseCode{
@@ -21,11 +52,21 @@ var ErrorResponseCode = errorResponseCode{
 	MissingIdParameter:  "MISSING_ID_PARAMETER",
 	InvalidDataType:     "INVALID_DATA_TYPE",
 	Unauthorized:        "UNAUTHORIZED",
+	PreconditionFailed:  "PRECONDITION_FAILED",
+	MissingPrecondition: "MISSING_PRECONDITION",
 }
 
 type customValidationErrCode map[string]string
 
-var CustomValidationErrCode = customValidationErrCode{}
+// error codes for the custom validation tags, keyed by tag
+var CustomValidationErrCode = customValidationErrCode{
+	"pwdminlen":      "PASSWORD_TOO_SHORT",
+	"notblank":       "CONTENT_BLANK",
+	"maxchars":       "CONTENT_TOO_LONG",
+	"maxlines":       "TOO_MANY_LINES",
+	"maxlinks":       "TOO_MANY_LINKS",
+	"nocontrolchars": "INVALID_CHARACTERS",
+}
 
 type errorMessage struct {
 	ErrUserAlreadyExists string
@@ -35,6 +76,51 @@ type errorMessage struct {
 	BadCredentials       string
 	SilentRefreshFailed  string
 	TokenExpired         string
+	RecordNotFound       string
+	CommentTooDeep       string
+	InvalidParentComment string
+	InvalidReaction      string
+	CannotFollowSelf     string
+	TooManyMedia         string
+	InvalidMedia         string
+	UnsupportedMedia     string
+	MediaTooLarge        string
+	CorruptMedia         string
+	MissingPublishAt     string
+	PublishAtInPast      string
+	InvalidQuotedPost    string
+	CannotRepost         string
+	CannotEditRepost     string
+	VersionMismatch      string
+	MissingIfMatch       string
+	AdminOnly            string
+	InvalidExportFormat  string
+	InvalidImportMode    string
+	InvalidImportFormat  string
+	ImportTooLarge       string
+	ImportAborted        string
+	InvalidSignature     string
+	InvalidActivity      string
+	InvalidHandle        string
+	RemoteActorNotFound  string
+	ModeratorOnly        string
+	CannotReportOwnPost  string
+	AlreadyReported      string
+	ReportClaimed        string
+	ReportResolved       string
+	InvalidReportStatus  string
+	AccountSuspended     string
+	ContentRejected      string
+	InvalidDateRange     string
+	DateRangeTooLong     string
+	TooManyPinnedPosts   string
+	CannotPinPost        string
+	InvalidPinOrder      string
+	InvalidPollClosesAt  string
+	DuplicatePollOption  string
+	PollClosed           string
+	AlreadyVoted         string
+	InvalidPollVote      string
 }
 
 var ErrorMessage = errorMessage{
@@ -45,6 +131,92 @@ var ErrorMessage = errorMessage{
 	BadCredentials:       "bad credentials",
 	SilentRefreshFailed:  "silent refresh failed",
 	TokenExpired:         "token has invalid claims: token is expired",
+	RecordNotFound:       "record not found",
+	CommentTooDeep:       "replies cannot be nested any deeper",
+	InvalidParentComment: "parent comment does not belong to this post",
+	InvalidReaction:      "reaction is not one of the allowed emoji",
+	CannotFollowSelf:     "users cannot follow themselves",
+	TooManyMedia:         "too many media items attached to the post",
+	InvalidMedia:         "media items must be uploaded by the post's author",
+	UnsupportedMedia:     "only JPEG, PNG and GIF images are supported",
+	MediaTooLarge:        "file or image dimensions are too large",
+	CorruptMedia:         "image data is corrupt or truncated",
+	MissingPublishAt:     "scheduled posts need a publish time",
+	PublishAtInPast:      "publish time must be in the future",
+	InvalidQuotedPost:    "quoted post does not exist",
+	CannotRepost:         "only public posts can be reposted",
+	CannotEditRepost:     "reposts cannot be edited",
+	VersionMismatch:      "post has been modified since it was fetched",
+	MissingIfMatch:       "If-Match header is required",
+	AdminOnly:            "only admins can do this",
+	InvalidExportFormat:  "format must be one of json, csv or md",
+	InvalidImportMode:    "mode must be either atomic or best-effort",
+	InvalidImportFormat:  "format must be either json or csv",
+	ImportTooLarge:       "import has too many rows",
+	ImportAborted:        "not imported because another row was rejected",
+	InvalidSignature:     "request signature is missing or invalid",
+	InvalidActivity:      "activity is malformed or not meant for this server",
+	InvalidHandle:        "handle must look like user@domain",
+	RemoteActorNotFound:  "remote account could not be found",
+	ModeratorOnly:        "only moderators can do this",
+	CannotReportOwnPost:  "users cannot report their own posts",
+	AlreadyReported:      "post has already been reported by this user",
+	ReportClaimed:        "report is claimed by another moderator",
+	ReportResolved:       "report has already been resolved",
+	InvalidReportStatus:  "status must be one of open, claimed or resolved",
+	AccountSuspended:     "account is suspended",
+	ContentRejected:      "post was rejected by the content filter",
+	InvalidDateRange:     "from and to must be dates formatted as YYYY-MM-DD, with from not after to",
+	DateRangeTooLong:     "date range can span at most 366 days",
+	TooManyPinnedPosts:   "at most 3 posts can be pinned",
+	CannotPinPost:        "only published posts can be pinned",
+	InvalidPinOrder:      "postIds must list every pinned post exactly once",
+	InvalidPollClosesAt:  "closesAt must be after the post is published and at most 30 days away",
+	DuplicatePollOption:  "poll options must be distinct",
+	PollClosed:           "poll is not open for voting",
+	AlreadyVoted:         "you have already voted in this poll",
+	InvalidPollVote:      "optionIds must be options of the poll, and single choice polls take exactly one",
+}
+
+// errors returned by services that were caused by the request rather than the server
+var badRequestMessages = map[string]bool{
+	ErrorMessage.CommentTooDeep:       true,
+	ErrorMessage.InvalidParentComment: true,
+	ErrorMessage.InvalidReaction:      true,
+	ErrorMessage.CannotFollowSelf:     true,
+	ErrorMessage.TooManyMedia:         true,
+	ErrorMessage.InvalidMedia:         true,
+	ErrorMessage.CorruptMedia:         true,
+	ErrorMessage.MissingPublishAt:     true,
+	ErrorMessage.PublishAtInPast:      true,
+	ErrorMessage.InvalidQuotedPost:    true,
+	ErrorMessage.CannotRepost:         true,
+	ErrorMessage.CannotEditRepost:     true,
+	ErrorMessage.InvalidImportMode:    true,
+	ErrorMessage.ImportTooLarge:       true,
+	ErrorMessage.InvalidImportFormat:  true,
+	ErrorMessage.InvalidActivity:      true,
+	ErrorMessage.InvalidHandle:        true,
+	ErrorMessage.RemoteActorNotFound:  true,
+	ErrorMessage.CannotReportOwnPost:  true,
+	ErrorMessage.AlreadyReported:      true,
+	ErrorMessage.ReportClaimed:        true,
+	ErrorMessage.ReportResolved:       true,
+	ErrorMessage.InvalidReportStatus:  true,
+	ErrorMessage.ContentRejected:      true,
+	ErrorMessage.TooManyPinnedPosts:   true,
+	ErrorMessage.CannotPinPost:        true,
+	ErrorMessage.InvalidPinOrder:      true,
+	ErrorMessage.InvalidPollClosesAt:  true,
+	ErrorMessage.DuplicatePollOption:  true,
+	ErrorMessage.PollClosed:           true,
+	ErrorMessage.AlreadyVoted:         true,
+	ErrorMessage.InvalidPollVote:      true,
+}
+
+// reports whether an error message returned by a service was caused by the request
+func IsBadRequestMessage(message string) bool {
+	return badRequestMessages[message]
 }
 
 type jwtConstants struct {
@@ -82,3 +254,373 @@ var ContextKeyConstants = contextKeyConstants{
 	UserId:       contextKey("user_id"),
 	RefreshToken: contextKey("refresh_token"),
 }
+
+type paginationConstants struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+var PaginationConstants = paginationConstants{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+type commentConstants struct {
+	MaxDepth int
+}
+
+var CommentConstants = commentConstants{
+	// top-level comments have a depth of 0
+	MaxDepth: getEnvInt("COMMENT_MAX_DEPTH", 3),
+}
+
+type reactionConstants struct {
+	AllowedEmoji []string
+}
+
+var ReactionConstants = reactionConstants{
+	AllowedEmoji: []string{"👍", "❤️", "😂", "😮", "😢", "🎉"},
+}
+
+type notificationKind struct {
+	Mention string
+	// a comment on the user's post, or a reply to their comment
+	Reply    string
+	Reaction string
+	Follow   string
+	// a report the user filed was resolved
+	Moderation string
+}
+
+var NotificationKind = notificationKind{
+	Mention:    "mention",
+	Reply:      "reply",
+	Reaction:   "reaction",
+	Follow:     "follow",
+	Moderation: "moderation",
+}
+
+type notificationConstants struct {
+	// how many of the people behind a group of notifications are listed by name
+	MaxGroupActors int
+}
+
+var NotificationConstants = notificationConstants{
+	MaxGroupActors: 3,
+}
+
+type mediaConstants struct {
+	MaxUploadBytes int64
+	MaxPixels      int
+	ThumbnailSize  int
+	MaxPerPost     int
+}
+
+var MediaConstants = mediaConstants{
+	MaxUploadBytes: int64(getEnvInt("MEDIA_MAX_UPLOAD_BYTES", 5<<20)),
+	MaxPixels:      getEnvInt("MEDIA_MAX_PIXELS", 40_000_000),
+	ThumbnailSize:  320,
+	MaxPerPost:     getEnvInt("MEDIA_MAX_PER_POST", 4),
+}
+
+type postStatus struct {
+	Draft     string
+	Scheduled string
+	Published string
+	// caught by a content filter and waiting for a moderator
+	Held string
+}
+
+var PostStatus = postStatus{
+	Draft:     "draft",
+	Scheduled: "scheduled",
+	Published: "published",
+	Held:      "held",
+}
+
+type postVisibility struct {
+	Public    string
+	Followers string
+	Private   string
+}
+
+var PostVisibility = postVisibility{
+	Public:    "public",
+	Followers: "followers",
+	Private:   "private",
+}
+
+type schedulerConstants struct {
+	PublishInterval  time.Duration
+	PublishBatchSize int
+}
+
+var SchedulerConstants = schedulerConstants{
+	PublishInterval:  time.Duration(getEnvInt("SCHEDULER_INTERVAL_SECONDS", 15)) * time.Second,
+	PublishBatchSize: 100,
+}
+
+type concurrencyConstants struct {
+	RequireIfMatch bool
+}
+
+var ConcurrencyConstants = concurrencyConstants{
+	RequireIfMatch: getEnvBool("REQUIRE_IF_MATCH", false),
+}
+
+type contentConstants struct {
+	// counted in user-perceived characters rather than bytes
+	MaxChars int
+	MaxLines int
+	MaxLinks int
+	// the posts.content column is a TEXT, which holds 64KiB
+	MaxBytes int
+}
+
+var ContentConstants = contentConstants{
+	MaxChars: getEnvInt("POST_MAX_CHARS", 5000),
+	MaxLines: getEnvInt("POST_MAX_LINES", 200),
+	MaxLinks: getEnvInt("POST_MAX_LINKS", 10),
+	MaxBytes: 65535,
+}
+
+type pinConstants struct {
+	// how many posts a user can pin to the top of their profile
+	MaxPinned int
+}
+
+var PinConstants = pinConstants{
+	MaxPinned: 3,
+}
+
+type pollConstants struct {
+	// how long a poll may stay open for
+	MaxDuration time.Duration
+}
+
+var PollConstants = pollConstants{
+	MaxDuration: 30 * 24 * time.Hour,
+}
+
+type userRole struct {
+	User      string
+	Moderator string
+	Admin     string
+}
+
+var UserRole = userRole{
+	User:      "user",
+	Moderator: "moderator",
+	Admin:     "admin",
+}
+
+type exportFormat struct {
+	JSON     string
+	CSV      string
+	Markdown string
+}
+
+var ExportFormat = exportFormat{
+	JSON:     "json",
+	CSV:      "csv",
+	Markdown: "md",
+}
+
+type importMode struct {
+	Atomic     string
+	BestEffort string
+}
+
+var ImportMode = importMode{
+	Atomic:     "atomic",
+	BestEffort: "best-effort",
+}
+
+type importRowStatus struct {
+	Imported  string
+	Duplicate string
+	Rejected  string
+}
+
+var ImportRowStatus = importRowStatus{
+	Imported:  "imported",
+	Duplicate: "duplicate",
+	Rejected:  "rejected",
+}
+
+type importConstants struct {
+	MaxBytes int64
+	MaxRows  int
+}
+
+var ImportConstants = importConstants{
+	MaxBytes: int64(getEnvInt("IMPORT_MAX_BYTES", 10<<20)),
+	MaxRows:  getEnvInt("IMPORT_MAX_ROWS", 1000),
+}
+
+type feedConstants struct {
+	ItemLimit int
+	// where the frontend lives, entries link to the post pages there and feeds are
+	// identified by their path under it, so it has to serve /feeds too
+	SiteURL string
+	Title   string
+}
+
+var FeedConstants = feedConstants{
+	ItemLimit: getEnvInt("FEED_ITEM_LIMIT", 50),
+	SiteURL:   getEnvString("FEED_SITE_URL", "http://localhost:5173"),
+	Title:     getEnvString("FEED_TITLE", "Posts"),
+}
+
+type federationConstants struct {
+	// the host part of acct: handles, e.g. example.com for @alice@example.com
+	Domain string
+	// where this backend is reachable from other instances, actor and object IDs are built on it
+	BaseURL string
+	// lets remote instances be reached over plain HTTP, only meant for testing against local instances
+	AllowHTTP bool
+	// lets remote instances live on private and loopback addresses, otherwise
+	// URLs other servers hand us can't be used to reach into the local network
+	AllowPrivate bool
+	// how long a signed request stays valid, allowing for clock skew between instances
+	SignatureMaxAge   time.Duration
+	DeliveryInterval  time.Duration
+	DeliveryBatchSize int
+	// attempts before a delivery is given up on, retries back off exponentially
+	DeliveryMaxAttempts int
+	// wait before the first retry, doubled for every attempt after it
+	DeliveryRetryDelay time.Duration
+	// a claimed delivery that isn't finished in this time is picked up again by any instance
+	DeliveryLease   time.Duration
+	RequestTimeout  time.Duration
+	MaxInboxBytes   int64
+	OutboxPageLimit int
+}
+
+var FederationConstants = federationConstants{
+	Domain:              getEnvString("FEDERATION_DOMAIN", "localhost:8080"),
+	BaseURL:             getEnvString("FEDERATION_BASE_URL", "http://localhost:8080"),
+	AllowHTTP:           getEnvBool("FEDERATION_ALLOW_HTTP", false),
+	AllowPrivate:        getEnvBool("FEDERATION_ALLOW_PRIVATE", false),
+	SignatureMaxAge:     time.Hour,
+	DeliveryInterval:    time.Duration(getEnvInt("FEDERATION_DELIVERY_INTERVAL_SECONDS", 10)) * time.Second,
+	DeliveryBatchSize:   50,
+	DeliveryMaxAttempts: getEnvInt("FEDERATION_DELIVERY_MAX_ATTEMPTS", 8),
+	DeliveryRetryDelay:  time.Minute,
+	DeliveryLease:       5 * time.Minute,
+	RequestTimeout:      10 * time.Second,
+	MaxInboxBytes:       1 << 20,
+	OutboxPageLimit:     20,
+}
+
+// delivered jobs are deleted, failed ones are kept around for inspection
+type deliveryStatus struct {
+	Pending string
+	Failed  string
+}
+
+var DeliveryStatus = deliveryStatus{
+	Pending: "pending",
+	Failed:  "failed",
+}
+
+type reportStatus struct {
+	Open     string
+	Claimed  string
+	Resolved string
+}
+
+var ReportStatus = reportStatus{
+	Open:     "open",
+	Claimed:  "claimed",
+	Resolved: "resolved",
+}
+
+type moderationAction struct {
+	Dismiss       string
+	HidePost      string
+	DeletePost    string
+	SuspendAuthor string
+}
+
+var ModerationAction = moderationAction{
+	Dismiss:       "dismiss",
+	HidePost:      "hide_post",
+	DeletePost:    "delete_post",
+	SuspendAuthor: "suspend_author",
+}
+
+type filterConstants struct {
+	// JSON file listing the content filters, filtering is off while it doesn't exist
+	ConfigPath string
+	// how often the file is checked for changes
+	ReloadInterval time.Duration
+}
+
+var FilterConstants = filterConstants{
+	ConfigPath:     getEnvString("CONTENT_FILTER_CONFIG", "content_filters.json"),
+	ReloadInterval: time.Duration(getEnvInt("CONTENT_FILTER_RELOAD_SECONDS", 30)) * time.Second,
+}
+
+type linkPreviewConstants struct {
+	// how many links of a post get a preview, the rest are left as they are
+	MaxPerPost int
+	// lets previews be fetched from loopback and private addresses, only meant for testing against local servers
+	AllowPrivate  bool
+	FetchInterval time.Duration
+	BatchSize     int
+	// attempts before a link is given up on until its preview goes stale
+	MaxAttempts int
+	// wait before the first retry, doubled for every attempt after it
+	RetryDelay time.Duration
+	// a claimed fetch that isn't finished in this time is picked up again
+	FetchLease     time.Duration
+	RequestTimeout time.Duration
+	// pages are read up to this size, metadata lives in the head so that's plenty
+	MaxBodyBytes int64
+	// previews are fetched again once they're this old and a post links to them
+	CacheTTL time.Duration
+}
+
+var LinkPreviewConstants = linkPreviewConstants{
+	MaxPerPost:     getEnvInt("LINK_PREVIEW_MAX_PER_POST", 3),
+	AllowPrivate:   getEnvBool("LINK_PREVIEW_ALLOW_PRIVATE", false),
+	FetchInterval:  time.Duration(getEnvInt("LINK_PREVIEW_INTERVAL_SECONDS", 5)) * time.Second,
+	BatchSize:      20,
+	MaxAttempts:    3,
+	RetryDelay:     time.Minute,
+	FetchLease:     time.Minute,
+	RequestTimeout: 5 * time.Second,
+	MaxBodyBytes:   512 << 10,
+	CacheTTL:       time.Duration(getEnvInt("LINK_PREVIEW_CACHE_HOURS", 24)) * time.Hour,
+}
+
+type linkPreviewStatus struct {
+	Pending string
+	Ready   string
+	// the page couldn't be fetched or had nothing to show
+	Failed string
+}
+
+var LinkPreviewStatus = linkPreviewStatus{
+	Pending: "pending",
+	Ready:   "ready",
+	Failed:  "failed",
+}
+
+type viewConstants struct {
+	// repeated views of a post by the same viewer within this time count once
+	DedupWindow time.Duration
+	// how often counted views are written to the database
+	FlushInterval time.Duration
+	// analytics cover the last DefaultAnalyticsDays unless a range is asked for, which can't be longer than MaxAnalyticsDays
+	DefaultAnalyticsDays int
+	MaxAnalyticsDays     int
+}
+
+var ViewConstants = viewConstants{
+	DedupWindow:          time.Duration(getEnvInt("VIEW_DEDUP_MINUTES", 30)) * time.Minute,
+	FlushInterval:        time.Duration(getEnvInt("VIEW_FLUSH_SECONDS", 30)) * time.Second,
+	DefaultAnalyticsDays: 30,
+	MaxAnalyticsDays:     366,
+}
@@ -0,0 +1,19 @@
+package models
+
+type AnalyticsDay struct {
+	// YYYY-MM-DD, days run from midnight to midnight UTC
+	Date         string `json:"date"`
+	Views        uint64 `json:"views"`
+	Reactions    uint64 `json:"reactions"`
+	NewFollowers uint64 `json:"newFollowers"`
+}
+
+// how an author's posts did over a range of days, every day in the range is listed even without activity
+type AnalyticsResponse struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Views        uint64         `json:"views"`
+	Reactions    uint64         `json:"reactions"`
+	NewFollowers uint64         `json:"newFollowers"`
+	Days         []AnalyticsDay `json:"days"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID        uint64    `db:"id"`
+	PostID    uint64    `db:"post_id"`
+	UserID    uint64    `db:"user_id"`
+	ParentID  *uint64   `db:"parent_id"`
+	RootID    *uint64   `db:"root_id"`
+	Depth     int       `db:"depth"`
+	Content   string    `db:"content"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type CommentRequest struct {
+	Content  string  `json:"content" validate:"required"`
+	ParentID *uint64 `json:"parentId"`
+}
+
+type CommentUpdateRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
+type CommentResponse struct {
+	ID        uint64             `json:"id"`
+	PostID    uint64             `json:"postId"`
+	ParentID  *uint64            `json:"parentId"`
+	UserID    uint64             `json:"userId"`
+	UserName  string             `json:"userName"`
+	Depth     int                `json:"depth"`
+	Content   string             `json:"content"`
+	CreatedAt time.Time          `json:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+	Replies   []*CommentResponse `json:"replies"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// a post as written to an export, with everything needed to restore or analyse it
+type ExportedPost struct {
+	ID         uint64     `json:"id"`
+	UserID     uint64     `json:"userId"`
+	UserName   string     `json:"userName"`
+	Content    string     `json:"content"`
+	Status     string     `json:"status"`
+	Visibility string     `json:"visibility"`
+	PublishAt  *time.Time `json:"publishAt"`
+	RepostOfID *uint64    `json:"repostOfId"`
+	QuoteOfID  *uint64    `json:"quoteOfId"`
+	Tags       []string   `json:"tags"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
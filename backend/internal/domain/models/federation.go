@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type RemoteFollowRequest struct {
+	// user@domain, with or without a leading @
+	Handle string `json:"handle" validate:"required,max=255"`
+}
+
+type RemoteActorResponse struct {
+	ID       uint64 `json:"id"`
+	ActorURI string `json:"actorUri"`
+	Handle   string `json:"handle"`
+	// false until the remote instance accepts the follow
+	Accepted  bool      `json:"accepted"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RemotePostResponse struct {
+	ID          uint64     `json:"id"`
+	ObjectURI   string     `json:"objectUri"`
+	URL         *string    `json:"url"`
+	ContentHTML string     `json:"contentHtml"`
+	ActorID     uint64     `json:"actorId"`
+	ActorURI    string     `json:"actorUri"`
+	Handle      string     `json:"handle"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
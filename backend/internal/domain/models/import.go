@@ -0,0 +1,37 @@
+package models
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"time"
+)
+
+// a row of an import file, the JSON and CSV layouts written by the export are accepted as they are
+type ImportedPost struct {
+	// identifies the row across runs, derived from the timestamp and content when left out
+	ImportKey  string     `json:"importKey" validate:"max=191"`
+	Content    string     `json:"content" validate:"required,notblank,maxchars,maxlines,maxlinks,nocontrolchars"`
+	Status     string     `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	Visibility string     `json:"visibility" validate:"omitempty,oneof=public followers private"`
+	PublishAt  *time.Time `json:"publishAt"`
+	CreatedAt  *time.Time `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt"`
+}
+
+type ImportRowResult struct {
+	// 1-based, not counting the CSV header
+	Row       int                `json:"row"`
+	ImportKey string             `json:"importKey"`
+	Status    string             `json:"status"`
+	PostID    *uint64            `json:"postId"`
+	Errors    []httpcommon.Error `json:"errors"`
+}
+
+type ImportReport struct {
+	Mode string `json:"mode"`
+	// false when an atomic import was rolled back
+	Committed  bool              `json:"committed"`
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Rejected   int               `json:"rejected"`
+	Rows       []ImportRowResult `json:"rows"`
+}
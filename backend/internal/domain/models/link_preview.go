@@ -0,0 +1,10 @@
+package models
+
+// the card shown for a link in a post, fields the page didn't provide are null
+type LinkPreviewResponse struct {
+	URL         string  `json:"url"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	ImageURL    *string `json:"imageUrl"`
+	SiteName    *string `json:"siteName"`
+}
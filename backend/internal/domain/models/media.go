@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+type Media struct {
+	ID           uint64    `db:"id"`
+	UserID       uint64    `db:"user_id"`
+	StorageKey   string    `db:"storage_key"`
+	ThumbnailKey string    `db:"thumbnail_key"`
+	URL          string    `db:"url"`
+	ThumbnailURL string    `db:"thumbnail_url"`
+	MimeType     string    `db:"mime_type"`
+	Width        int       `db:"width"`
+	Height       int       `db:"height"`
+	SizeBytes    int       `db:"size_bytes"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+type MediaResponse struct {
+	ID           uint64    `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl"`
+	MimeType     string    `json:"mimeType"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	SizeBytes    int       `json:"sizeBytes"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type PostMediaRequest struct {
+	ID      uint64 `json:"id" validate:"required"`
+	AltText string `json:"altText" validate:"max=1000"`
+}
+
+type PostMediaResponse struct {
+	ID           uint64 `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	MimeType     string `json:"mimeType"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	AltText      string `json:"altText"`
+}
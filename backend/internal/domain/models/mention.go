@@ -0,0 +1,10 @@
+package models
+
+// a resolved @username in a post's content, offsets are in Unicode code points
+// and cover the leading '@', End is exclusive
+type MentionEntity struct {
+	UserID   uint64 `json:"userId"`
+	UserName string `json:"userName"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID        uint64     `db:"id"`
+	UserID    uint64     `db:"user_id"`
+	Kind      string     `db:"kind"`
+	ActorID   uint64     `db:"actor_id"`
+	PostID    *uint64    `db:"post_id"`
+	CommentID *uint64    `db:"comment_id"`
+	ReadAt    *time.Time `db:"read_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type NotificationActor struct {
+	ID       uint64 `json:"id"`
+	UserName string `json:"userName"`
+}
+
+// reactions and follows are grouped by post into one entry, everything else stands on its own
+type NotificationResponse struct {
+	// the latest notification of the group, marking it read marks the whole group read
+	ID        uint64  `json:"id"`
+	Kind      string  `json:"kind"`
+	PostID    *uint64 `json:"postId"`
+	CommentID *uint64 `json:"commentId"`
+	// the most recent people behind the group, empty for moderation notifications
+	// which don't say who handled the report
+	Actors []NotificationActor `json:"actors"`
+	// how many different people the group is about, as in "3 people reacted"
+	ActorCount uint64    `json:"actorCount"`
+	Unread     bool      `json:"unread"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type NotificationPreferencesRequest struct {
+	// kinds the user doesn't want to be notified about, replaces the current list
+	Muted []string `json:"muted" validate:"required,dive,oneof=mention reply reaction follow moderation"`
+}
+
+type NotificationPreferencesResponse struct {
+	Muted []string `json:"muted"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// cursor-based pagination, the cursor being the ID of the last item on the previous page
+type PageRequest struct {
+	Cursor uint64
+	Limit  int
+}
+
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *uint64 `json:"nextCursor"`
+}
@@ -0,0 +1,6 @@
+package models
+
+type PinOrderRequest struct {
+	// every pinned post, first one first
+	PostIDs []uint64 `json:"postIds" validate:"required,max=3,dive,required"`
+}
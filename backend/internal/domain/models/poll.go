@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type PollRequest struct {
+	Options        []string  `json:"options" validate:"required,min=2,max=6,dive,required,notblank,max=100,nocontrolchars"`
+	MultipleChoice bool      `json:"multipleChoice"`
+	ClosesAt       time.Time `json:"closesAt" validate:"required"`
+	// keep the tallies from voters until they've voted or the poll has closed
+	HideResults bool `json:"hideResults"`
+}
+
+type PollVoteRequest struct {
+	OptionIDs []uint64 `json:"optionIds" validate:"required,min=1,max=6,dive,required"`
+}
+
+type PollOptionResponse struct {
+	ID   uint64 `json:"id"`
+	Text string `json:"text"`
+	// null while the results are hidden from the viewer
+	Votes *uint64 `json:"votes"`
+}
+
+type PollResponse struct {
+	ID             uint64    `json:"id"`
+	MultipleChoice bool      `json:"multipleChoice"`
+	HideResults    bool      `json:"hideResults"`
+	ClosesAt       time.Time `json:"closesAt"`
+	Closed         bool      `json:"closed"`
+	// how many people voted, null while the results are hidden from the viewer
+	VoterCount *uint64              `json:"voterCount"`
+	Options    []PollOptionResponse `json:"options"`
+	// the options the viewer picked, empty until they vote
+	MyVotes []uint64 `json:"myVotes"`
+}
@@ -3,22 +3,64 @@ package models
 import "time"
 
 type Post struct {
-	ID        uint64    `db:"id"`
-	Content   string    `db:"content"`
-	UserID    uint64    `db:"user_id"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID          uint64     `db:"id"`
+	Content     string     `db:"content"`
+	ContentHTML string     `db:"content_html"`
+	UserID      uint64     `db:"user_id"`
+	Status      string     `db:"status"`
+	PublishAt   *time.Time `db:"publish_at"`
+	Visibility  string     `db:"visibility"`
+	RepostOfID  *uint64    `db:"repost_of_id"`
+	QuoteOfID   *uint64    `db:"quote_of_id"`
+	Version     uint64     `db:"version"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
 }
 
 type PostRequest struct {
-	Content string `json:"content" validate:"required"`
+	Content string `json:"content" validate:"required,notblank,maxchars,maxlines,maxlinks,nocontrolchars"`
+	// leaving it out of an update keeps the attached media as they are
+	Media []PostMediaRequest `json:"media" validate:"omitempty,dive"`
+	// defaults to published for new posts, and to the current status for updates
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time `json:"publishAt"`
+	// defaults to public for new posts, and to the current visibility for updates
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public followers private"`
+	// only read when creating a post, quote posts can't be re-pointed later
+	QuoteOfID *uint64 `json:"quoteOfId"`
+	// only read when creating a post, polls can't be changed once people may have voted
+	Poll *PollRequest `json:"poll" validate:"omitempty"`
 }
 
 type PostResponse struct {
-	ID        uint64    `json:"id"`
-	Content   string    `json:"content"`
-	UserID    uint64    `json:"userId"`
-	UserName  string    `json:"userName"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID             uint64              `json:"id"`
+	Content        string              `json:"content"`
+	ContentHTML    string              `json:"contentHtml"`
+	UserID         uint64              `json:"userId"`
+	UserName       string              `json:"userName"`
+	Status         string              `json:"status"`
+	PublishAt      *time.Time          `json:"publishAt"`
+	Visibility     string              `json:"visibility"`
+	CreatedAt      time.Time           `json:"createdAt"`
+	UpdatedAt      time.Time           `json:"updatedAt"`
+	CommentCount   uint64              `json:"commentCount"`
+	Reactions      []ReactionSummary   `json:"reactions"`
+	Mentions       []MentionEntity     `json:"mentions"`
+	BookmarkedByMe bool                `json:"bookmarkedByMe"`
+	Media          []PostMediaResponse `json:"media"`
+	RepostOfID     *uint64             `json:"repostOfId"`
+	QuoteOfID      *uint64             `json:"quoteOfId"`
+	// the post being reposted or quoted, null when it was deleted or the viewer can't see it
+	ReferencedPost *PostResponse `json:"referencedPost"`
+	RepostCount    uint64        `json:"repostCount"`
+	QuoteCount     uint64        `json:"quoteCount"`
+	RepostedByMe   bool          `json:"repostedByMe"`
+	Version        uint64        `json:"version"`
+	// hidden by a moderator, only its author still sees it
+	Hidden bool `json:"hidden"`
+	// previews show up once they've been fetched, links without one are left out
+	LinkPreviews []LinkPreviewResponse `json:"linkPreviews"`
+	ViewCount    uint64                `json:"viewCount"`
+	Pinned       bool                  `json:"pinned"`
+	Poll         *PollResponse         `json:"poll"`
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Reaction struct {
+	ID        uint64    `db:"id"`
+	PostID    uint64    `db:"post_id"`
+	UserID    uint64    `db:"user_id"`
+	Emoji     string    `db:"emoji"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type ReactionSummary struct {
+	Emoji       string `json:"emoji"`
+	Count       uint64 `json:"count"`
+	ReactedByMe bool   `json:"reactedByMe"`
+}
+
+type ReactorResponse struct {
+	ID        uint64    `json:"id"`
+	UserID    uint64    `json:"userId"`
+	UserName  string    `json:"userName"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"createdAt"`
+}
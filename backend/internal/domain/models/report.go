@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type ReportRequest struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam harassment hate violence nudity misinformation other"`
+	Details string `json:"details" validate:"max=1000,nocontrolchars"`
+}
+
+type ResolveReportRequest struct {
+	Action string `json:"action" validate:"required,oneof=dismiss hide_post delete_post suspend_author"`
+	// shown to moderators only, reporters just learn that their report was handled
+	Note string `json:"note" validate:"max=1000,nocontrolchars"`
+}
+
+type ReportResponse struct {
+	ID uint64 `json:"id"`
+	// null once the post has been deleted
+	PostID      *uint64 `json:"postId"`
+	PostContent *string `json:"postContent"`
+	AuthorID    uint64  `json:"authorId"`
+	AuthorName  string  `json:"authorName"`
+	// null for reports raised by a content filter, which name the filter instead
+	ReporterID     *uint64    `json:"reporterId"`
+	ReporterName   *string    `json:"reporterName"`
+	Filter         *string    `json:"filter"`
+	Reason         string     `json:"reason"`
+	Details        *string    `json:"details"`
+	Status         string     `json:"status"`
+	ModeratorID    *uint64    `json:"moderatorId"`
+	Action         *string    `json:"action"`
+	ResolutionNote *string    `json:"resolutionNote"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ClaimedAt      *time.Time `json:"claimedAt"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"chi-mysql-boilerplate/internal/utils/diff"
+)
+
+type PostRevision struct {
+	ID        uint64    `db:"id"`
+	PostID    uint64    `db:"post_id"`
+	Content   string    `db:"content"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type PostRevisionResponse struct {
+	ID        uint64    `json:"id"`
+	PostID    uint64    `json:"postId"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PostRevisionDetailResponse struct {
+	PostRevisionResponse
+	Diff []diff.Line `json:"diff"`
+}
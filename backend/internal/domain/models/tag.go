@@ -0,0 +1,11 @@
+package models
+
+type Tag struct {
+	ID   uint64 `db:"id"`
+	Name string `db:"name"`
+}
+
+type TagResponse struct {
+	Name      string `json:"name"`
+	PostCount uint64 `json:"postCount"`
+}
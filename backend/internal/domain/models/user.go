@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type UserProfileResponse struct {
+	ID             uint64 `json:"id"`
+	UserName       string `json:"userName"`
+	FollowerCount  uint64 `json:"followerCount"`
+	FollowingCount uint64 `json:"followingCount"`
+	FollowedByMe   bool   `json:"followedByMe"`
+	// the pinned posts the viewer can see, in pinned order
+	PinnedPosts []*PostResponse `json:"pinnedPosts"`
+}
+
+type FollowResponse struct {
+	ID        uint64    `json:"id"`
+	UserID    uint64    `json:"userId"`
+	UserName  string    `json:"userName"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,174 @@
+package filters
+
+import (
+	"chi-mysql-boilerplate/internal/utils/entities"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("banned_words", newBannedWords)
+	Register("link_limit", newLinkLimit)
+	Register("duplicate", newDuplicate)
+	Register("account_age", newAccountAge)
+}
+
+// matches posts containing any of a list of words, ignoring case. a * in a word stands for
+// any number of characters and a ? for exactly one, neither of them crosses whitespace
+type bannedWords struct {
+	pattern *regexp.Regexp
+}
+
+func newBannedWords(config json.RawMessage) (Filter, error) {
+	var options struct {
+		Words []string `json:"words"`
+	}
+	if err := json.Unmarshal(config, &options); err != nil {
+		return nil, err
+	}
+
+	alternatives := []string{}
+	for _, word := range options.Words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		quoted := regexp.QuoteMeta(word)
+		quoted = strings.ReplaceAll(quoted, `\*`, `\S*`)
+		quoted = strings.ReplaceAll(quoted, `\?`, `\S`)
+		alternatives = append(alternatives, quoted)
+	}
+	if len(alternatives) == 0 {
+		return nil, errors.New("words must list at least one word")
+	}
+
+	// \b only knows about ASCII, so word boundaries are spelled out
+	pattern, err := regexp.Compile(`(?i)(?:^|[^\p{L}\p{N}_])(` + strings.Join(alternatives, "|") + `)(?:$|[^\p{L}\p{N}_])`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bannedWords{pattern: pattern}, nil
+}
+
+func (f *bannedWords) Match(ctx context.Context, db Querier, post Post) (string, bool, error) {
+	match := f.pattern.FindStringSubmatch(post.Content)
+	if match == nil {
+		return "", false, nil
+	}
+	return fmt.Sprintf("contains the banned word %q", match[1]), true, nil
+}
+
+// matches posts with more links than allowed
+type linkLimit struct {
+	max int
+}
+
+func newLinkLimit(config json.RawMessage) (Filter, error) {
+	var options struct {
+		Max *int `json:"max"`
+	}
+	if err := json.Unmarshal(config, &options); err != nil {
+		return nil, err
+	}
+	if options.Max == nil || *options.Max < 0 {
+		return nil, errors.New("max must be zero or more")
+	}
+
+	return &linkLimit{max: *options.Max}, nil
+}
+
+func (f *linkLimit) Match(ctx context.Context, db Querier, post Post) (string, bool, error) {
+	count := len(entities.Links(post.Content))
+	if count <= f.max {
+		return "", false, nil
+	}
+	return fmt.Sprintf("contains %d links, at most %d are allowed", count, f.max), true, nil
+}
+
+// matches posts whose author already posted the exact same content maxCopies times within the window,
+// reposts don't count since they have no content of their own
+type duplicate struct {
+	window    time.Duration
+	maxCopies int
+}
+
+func newDuplicate(config json.RawMessage) (Filter, error) {
+	options := struct {
+		Window    Duration `json:"window"`
+		MaxCopies int      `json:"maxCopies"`
+	}{MaxCopies: 1}
+	if err := json.Unmarshal(config, &options); err != nil {
+		return nil, err
+	}
+	if options.Window <= 0 {
+		return nil, errors.New("window must be a positive duration")
+	}
+	if options.MaxCopies < 1 {
+		return nil, errors.New("maxCopies must be at least 1")
+	}
+
+	return &duplicate{window: time.Duration(options.Window), maxCopies: options.MaxCopies}, nil
+}
+
+func (f *duplicate) Match(ctx context.Context, db Querier, post Post) (string, bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM posts
+		WHERE user_id = ? AND id != ? AND repost_of_id IS NULL AND content = ? AND created_at >= ?
+	`
+	var copies int
+	if err := db.QueryRowContext(ctx, query, post.UserID, post.ID, post.Content, time.Now().Add(-f.window)).Scan(&copies); err != nil {
+		return "", false, err
+	}
+	if copies < f.maxCopies {
+		return "", false, nil
+	}
+	return fmt.Sprintf("the same content was posted %d times in the last %s", copies, f.window), true, nil
+}
+
+// matches posts by accounts younger than minAge. when maxLinks is set, only posts with
+// more links than that are matched, so new accounts can still post plain text
+type accountAge struct {
+	minAge   time.Duration
+	maxLinks *int
+}
+
+func newAccountAge(config json.RawMessage) (Filter, error) {
+	var options struct {
+		MinAge   Duration `json:"minAge"`
+		MaxLinks *int     `json:"maxLinks"`
+	}
+	if err := json.Unmarshal(config, &options); err != nil {
+		return nil, err
+	}
+	if options.MinAge <= 0 {
+		return nil, errors.New("minAge must be a positive duration")
+	}
+	if options.MaxLinks != nil && *options.MaxLinks < 0 {
+		return nil, errors.New("maxLinks must be zero or more")
+	}
+
+	return &accountAge{minAge: time.Duration(options.MinAge), maxLinks: options.MaxLinks}, nil
+}
+
+func (f *accountAge) Match(ctx context.Context, db Querier, post Post) (string, bool, error) {
+	age := time.Since(post.AccountCreatedAt)
+	if age >= f.minAge {
+		return "", false, nil
+	}
+	if f.maxLinks == nil {
+		return fmt.Sprintf("the account is younger than %s", f.minAge), true, nil
+	}
+
+	count := len(entities.Links(post.Content))
+	if count <= *f.maxLinks {
+		return "", false, nil
+	}
+	return fmt.Sprintf("the account is younger than %s and the post contains %d links", f.minAge, count), true, nil
+}
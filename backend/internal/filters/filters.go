@@ -0,0 +1,160 @@
+package filters
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// what happens to a post a filter matched
+const (
+	// publish the post, but put it in the moderation queue
+	ActionFlag = "flag"
+	// keep the post from everyone but its author until a moderator releases it
+	ActionHold = "hold"
+	// refuse to save the post
+	ActionReject = "reject"
+)
+
+var severity = map[string]int{
+	ActionFlag:   1,
+	ActionHold:   2,
+	ActionReject: 3,
+}
+
+// satisfied by *sql.DB and *sql.Tx, for filters that look at other posts
+type Querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// the post being checked, ID is 0 for posts that are being created
+type Post struct {
+	ID               uint64
+	UserID           uint64
+	Content          string
+	AccountCreatedAt time.Time
+}
+
+type Filter interface {
+	// reports whether the post matches, with a reason meant for moderators
+	Match(ctx context.Context, db Querier, post Post) (string, bool, error)
+}
+
+// builds a filter from its entry in the config file
+type Factory func(config json.RawMessage) (Filter, error)
+
+var factories = map[string]Factory{}
+
+// make a filter type available to the config file, meant to be called from init functions
+func Register(filterType string, factory Factory) {
+	factories[filterType] = factory
+}
+
+// the strongest action any filter called for, along with the filter that did
+type Verdict struct {
+	Action string
+	Filter string
+	Reason string
+}
+
+type rule struct {
+	filterType string
+	action     string
+	filter     Filter
+}
+
+// the configured filters, run in the order they appear in the config file
+type Pipeline struct {
+	rules []rule
+}
+
+// run the post through every filter, stopping early once one rejects it.
+// returns nil when no filter matched
+func (p *Pipeline) Check(ctx context.Context, db Querier, post Post) (*Verdict, error) {
+	var verdict *Verdict
+	for _, rule := range p.rules {
+		reason, matched, err := rule.filter.Match(ctx, db, post)
+		if err != nil {
+			return nil, err
+		}
+		if !matched || (verdict != nil && severity[verdict.Action] >= severity[rule.action]) {
+			continue
+		}
+
+		verdict = &Verdict{Action: rule.action, Filter: rule.filterType, Reason: reason}
+		if rule.action == ActionReject {
+			break
+		}
+	}
+
+	return verdict, nil
+}
+
+type configFile struct {
+	Filters []json.RawMessage `json:"filters"`
+}
+
+type ruleConfig struct {
+	Type   string `json:"type"`
+	Action string `json:"action"`
+}
+
+// build a pipeline out of the contents of a config file
+func Parse(data []byte) (*Pipeline, error) {
+	var config configFile
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	pipeline := &Pipeline{}
+	for i, raw := range config.Filters {
+		var entry ruleConfig
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("filter %d: %w", i, err)
+		}
+		factory, ok := factories[entry.Type]
+		if !ok {
+			return nil, fmt.Errorf("filter %d: unknown type %q", i, entry.Type)
+		}
+		if _, ok = severity[entry.Action]; !ok {
+			return nil, fmt.Errorf("filter %d: action must be one of flag, hold or reject", i)
+		}
+
+		filter, err := factory(raw)
+		if err != nil {
+			return nil, fmt.Errorf("filter %d (%s): %w", i, entry.Type, err)
+		}
+		pipeline.rules = append(pipeline.rules, rule{filterType: entry.Type, action: entry.Action, filter: filter})
+	}
+
+	return pipeline, nil
+}
+
+var active atomic.Pointer[Pipeline]
+
+// the pipeline posts are currently checked against, empty until a config is loaded
+func Active() *Pipeline {
+	if pipeline := active.Load(); pipeline != nil {
+		return pipeline
+	}
+	return &Pipeline{}
+}
+
+// a duration written the way time.ParseDuration reads it, such as "10m" or "24h"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
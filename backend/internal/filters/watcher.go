@@ -0,0 +1,74 @@
+package filters
+
+import (
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// keeps the active pipeline in sync with the config file
+type Watcher struct {
+	path     string
+	interval time.Duration
+	modTime  time.Time
+	size     int64
+}
+
+func NewWatcher(path string, interval time.Duration) *Watcher {
+	return &Watcher{path: path, interval: interval}
+}
+
+// load the config file if it changed since the last load. a missing file turns filtering off,
+// while a broken one keeps the previous filters in place
+func (w *Watcher) Load() error {
+	info, err := os.Stat(w.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !w.modTime.IsZero() {
+			helpers.MessageLogs.InfoLog.Printf("content filter config %s was removed, filtering is off", w.path)
+		}
+		active.Store(&Pipeline{})
+		w.modTime, w.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	// remember the file either way so a broken config is only reported once
+	w.modTime, w.size = info.ModTime(), info.Size()
+	pipeline, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	active.Store(pipeline)
+	helpers.MessageLogs.InfoLog.Printf("loaded %d content filters from %s", len(pipeline.rules), w.path)
+	return nil
+}
+
+// reload the config file on every tick until the context is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Load(); err != nil {
+				helpers.MessageLogs.ErrorLog.Printf("content filter config %s: %v", w.path, err)
+			}
+		}
+	}
+}
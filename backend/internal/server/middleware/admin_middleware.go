@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"database/sql"
+	"net/http"
+)
+
+// only lets through users with the admin role, has to run after VerifyAccessToken.
+// the role is looked up on every request rather than read from the token,
+// so demoting an admin takes effect right away
+func RequireAdmin(db *sql.DB) func(http.Handler) http.Handler {
+	userService := services.NewUserService(db)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, _ := r.Context().Value(httpcommon.ContextKeyConstants.UserId).(uint64)
+
+			isAdmin, err := userService.IsAdmin(userId)
+			if err != nil && err != sql.ErrNoRows {
+				helpers.MessageLogs.ErrorLog.Println(err)
+				helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
+					httpcommon.Error{
+						Message: err.Error(),
+						Code:    httpcommon.ErrorResponseCode.InternalServerError,
+					}))
+				return
+			}
+			if !isAdmin {
+				helpers.WriteJSON(w, http.StatusForbidden, httpcommon.NewErrorResponse(
+					httpcommon.Error{
+						Message: httpcommon.ErrorMessage.AdminOnly,
+						Code:    httpcommon.ErrorResponseCode.Unauthorized,
+					}))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// only lets through moderators and admins, has to run after VerifyAccessToken
+func RequireModerator(db *sql.DB) func(http.Handler) http.Handler {
+	userService := services.NewUserService(db)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, _ := r.Context().Value(httpcommon.ContextKeyConstants.UserId).(uint64)
+
+			isModerator, err := userService.IsModerator(userId)
+			if err != nil && err != sql.ErrNoRows {
+				helpers.MessageLogs.ErrorLog.Println(err)
+				helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
+					httpcommon.Error{
+						Message: err.Error(),
+						Code:    httpcommon.ErrorResponseCode.InternalServerError,
+					}))
+				return
+			}
+			if !isModerator {
+				helpers.WriteJSON(w, http.StatusForbidden, httpcommon.NewErrorResponse(
+					httpcommon.Error{
+						Message: httpcommon.ErrorMessage.ModeratorOnly,
+						Code:    httpcommon.ErrorResponseCode.Unauthorized,
+					}))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// turns away suspended users, has to run after VerifyAccessToken. access tokens stay valid
+// until they expire, so suspension is checked on every request instead of only at login
+func RejectSuspended(db *sql.DB) func(http.Handler) http.Handler {
+	userService := services.NewUserService(db)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, _ := r.Context().Value(httpcommon.ContextKeyConstants.UserId).(uint64)
+
+			isSuspended, err := userService.IsSuspended(userId)
+			if err != nil && err != sql.ErrNoRows {
+				helpers.MessageLogs.ErrorLog.Println(err)
+				helpers.WriteJSON(w, http.StatusInternalServerError, httpcommon.NewErrorResponse(
+					httpcommon.Error{
+						Message: err.Error(),
+						Code:    httpcommon.ErrorResponseCode.InternalServerError,
+					}))
+				return
+			}
+			if isSuspended {
+				helpers.WriteJSON(w, http.StatusForbidden, httpcommon.NewErrorResponse(
+					httpcommon.Error{
+						Message: httpcommon.ErrorMessage.AccountSuspended,
+						Code:    httpcommon.ErrorResponseCode.Unauthorized,
+					}))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"chi-mysql-boilerplate/internal/utils/helpers"
 	"chi-mysql-boilerplate/internal/utils/jwt"
 	"context"
+	"errors"
 	"net/http"
 )
 
@@ -23,50 +24,80 @@ func getAccessToken(r *http.Request) string {
 
 func VerifyAccessToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accessToken := getAccessToken(r)
-		accessTokenClaims, err := jwt.VerifyToken(accessToken, false)
-		if err != nil {
-			if err.Error() == httpcommon.ErrorMessage.TokenExpired {
-				// token expired
-				helpers.MessageLogs.ErrorLog.Println(err)
-				helpers.WriteJSON(w, http.StatusUnauthorized, httpcommon.NewErrorResponse(
-					httpcommon.Error{
-						Message: httpcommon.ErrorMessage.TokenExpired,
-						Code:    httpcommon.ErrorResponseCode.Unauthorized,
-					}))
-				return
-			} else {
-				// handle other errors (e.g. undefined/empty token)
-				helpers.MessageLogs.ErrorLog.Println(err)
-				helpers.WriteJSON(w, http.StatusUnauthorized, httpcommon.NewErrorResponse(
-					httpcommon.Error{
-						Message: httpcommon.ErrorMessage.BadCredentials,
-						Code:    httpcommon.ErrorResponseCode.Unauthorized,
-					}))
-				return
-			}
+		userId, ok := authenticate(w, r)
+		if !ok {
+			return
 		}
 
-		// get the user ID from the access token
-		// you might think this makes the ID retrieval in AuthHandler.HandleRefreshToken redundant
-		// but if the access token expires this ID wouldn't exist in the context at all
-		payload, ok := accessTokenClaims.Payload.(map[string]interface{})
-		if !ok {
-			helpers.MessageLogs.ErrorLog.Println(err)
-			helpers.WriteJSON(w, http.StatusUnauthorized, httpcommon.NewErrorResponse(
-				httpcommon.Error{
-					Message: httpcommon.ErrorMessage.BadCredentials,
-					Code:    httpcommon.ErrorResponseCode.Unauthorized,
-				}))
+		ctx := context.WithValue(r.Context(), httpcommon.ContextKeyConstants.UserId, userId)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// same as VerifyAccessToken, except requests without a valid access token are let through anonymously
+// so public routes can still tell who's looking at them. an expired or malformed token isn't an error here,
+// a client holding a stale one can read whatever an anonymous visitor could
+func OptionalAccessToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if getAccessToken(r) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userId, err := verifyAccessToken(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
 			return
 		}
-		userId := uint64(payload["id"].(float64))
 
 		ctx := context.WithValue(r.Context(), httpcommon.ContextKeyConstants.UserId, userId)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// helper function to verify the access token and get the user ID out of it,
+// returns false (and writes the error response) if the token is invalid
+func authenticate(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	userId, err := verifyAccessToken(r)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		message := httpcommon.ErrorMessage.BadCredentials
+		if err.Error() == httpcommon.ErrorMessage.TokenExpired {
+			message = httpcommon.ErrorMessage.TokenExpired
+		}
+		helpers.WriteJSON(w, http.StatusUnauthorized, httpcommon.NewErrorResponse(
+			httpcommon.Error{
+				Message: message,
+				Code:    httpcommon.ErrorResponseCode.Unauthorized,
+			}))
+		return 0, false
+	}
+
+	return userId, true
+}
+
+// helper function to get the user ID out of the request's access token
+func verifyAccessToken(r *http.Request) (uint64, error) {
+	accessTokenClaims, err := jwt.VerifyToken(getAccessToken(r), false)
+	if err != nil {
+		return 0, err
+	}
+
+	// get the user ID from the access token
+	// you might think this makes the ID retrieval in AuthHandler.HandleRefreshToken redundant
+	// but if the access token expires this ID wouldn't exist in the context at all
+	payload, ok := accessTokenClaims.Payload.(map[string]interface{})
+	if !ok {
+		return 0, errors.New(httpcommon.ErrorMessage.BadCredentials)
+	}
+	id, ok := payload["id"].(float64)
+	if !ok {
+		return 0, errors.New(httpcommon.ErrorMessage.BadCredentials)
+	}
+
+	return uint64(id), nil
+}
+
 func ExtractRefreshToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// get the refresh token from the cookie
@@ -5,6 +5,7 @@ import (
 
 	"chi-mysql-boilerplate/internal/controllers"
 	"chi-mysql-boilerplate/internal/server/middleware"
+	"chi-mysql-boilerplate/internal/storage"
 	"chi-mysql-boilerplate/internal/utils/helpers"
 	"chi-mysql-boilerplate/internal/utils/validators"
 
@@ -15,16 +16,70 @@ import (
 func (s *Server) RegisterRoutes() http.Handler {
 	validator := validators.NewValidator(helpers.MessageLogs)
 
-	postHandler := controllers.NewPostHandler(s.db, validator)
+	postHandler := controllers.NewPostHandler(s.db, s.views, validator)
 	authHandler := controllers.NewAuthHandler(s.db, validator)
+	revisionHandler := controllers.NewRevisionHandler(s.db, validator)
+	commentHandler := controllers.NewCommentHandler(s.db, validator)
+	reactionHandler := controllers.NewReactionHandler(s.db)
+	tagHandler := controllers.NewTagHandler(s.db)
+	userHandler := controllers.NewUserHandler(s.db)
+	bookmarkHandler := controllers.NewBookmarkHandler(s.db)
+	mediaHandler := controllers.NewMediaHandler(s.db, s.storage)
+	repostHandler := controllers.NewRepostHandler(s.db)
+	exportHandler := controllers.NewExportHandler(s.db)
+	importHandler := controllers.NewImportHandler(s.db, validator)
+	feedHandler := controllers.NewFeedHandler(s.db)
+	federationHandler := controllers.NewFederationHandler(s.db, validator)
+	reportHandler := controllers.NewReportHandler(s.db, validator)
+	analyticsHandler := controllers.NewAnalyticsHandler(s.db)
+	pinHandler := controllers.NewPinHandler(s.db, validator)
+	pollHandler := controllers.NewPollHandler(s.db, validator)
+	notificationHandler := controllers.NewNotificationHandler(s.db, validator)
 
 	r := chi.NewRouter()
 	r.Use(chiMiddleware.Recoverer)
 	r.Use(middleware.Cors())
 
+	// uploads kept on the local filesystem are served by the backend itself
+	if localStorage, ok := s.storage.(*storage.LocalStorage); ok {
+		r.Handle("/media/*", http.StripPrefix("/media/", middleware.NoDirectoryListing(http.FileServer(http.Dir(localStorage.Dir)))))
+	}
+
+	// feeds are fetched by feed readers, so they live outside the API and only show public posts
+	r.Route("/feeds", func(feedRouter chi.Router) {
+		feedRouter.Get("/posts.atom", feedHandler.GetPostsAtom)
+		feedRouter.Get("/posts.rss", feedHandler.GetPostsRSS)
+		feedRouter.Get("/users/{file}", feedHandler.GetUserAtom)
+	})
+
+	// ActivityPub endpoints for other instances, inboxes authenticate requests by their HTTP signature
+	r.Get("/.well-known/webfinger", federationHandler.WebFinger)
+	r.Route("/ap", func(apRouter chi.Router) {
+		apRouter.Post("/inbox", federationHandler.PostInbox)
+		apRouter.Get("/users/{username}", federationHandler.GetActor)
+		apRouter.Get("/users/{username}/outbox", federationHandler.GetOutbox)
+		apRouter.Get("/users/{username}/followers", federationHandler.GetFollowers)
+		apRouter.Post("/users/{username}/inbox", federationHandler.PostInbox)
+		apRouter.Get("/posts/{id}", federationHandler.GetNote)
+	})
+
 	r.Route("/api/v1", func(v1 chi.Router) {
-		v1.Get("/posts", postHandler.GetAllPosts)
-		v1.Get("/posts/{id}", postHandler.GetPostById)
+		// public routes, where logging in only personalizes the response
+		v1.Group(func(v1 chi.Router) {
+			v1.Use(middleware.OptionalAccessToken)
+			v1.Get("/posts", postHandler.GetAllPosts)
+			v1.Get("/posts/{id}", postHandler.GetPostById)
+			v1.Get("/posts/by-user/{userId}", postHandler.GetPostsByUserId)
+			v1.Get("/posts/{id}/revisions", revisionHandler.GetRevisions)
+			v1.Get("/posts/{id}/revisions/{revisionId}", revisionHandler.GetRevisionById)
+			v1.Get("/posts/{id}/comments", commentHandler.GetComments)
+			v1.Get("/posts/{id}/reactions", reactionHandler.GetReactors)
+			v1.Get("/tags", tagHandler.SearchTags)
+			v1.Get("/tags/{tag}/posts", tagHandler.GetPostsByTag)
+			v1.Get("/users/{userId}", userHandler.GetProfile)
+			v1.Get("/users/{userId}/followers", userHandler.GetFollowers)
+			v1.Get("/users/{userId}/following", userHandler.GetFollowing)
+		})
 
 		v1.Post("/auth/register", authHandler.Register)
 		v1.Post("/auth/login", authHandler.Login)
@@ -32,10 +87,60 @@ func (s *Server) RegisterRoutes() http.Handler {
 		// protected routes
 		v1.Group(func(v1 chi.Router) {
 			v1.Use(middleware.VerifyAccessToken)
-			v1.Get("/posts/by-user/{userId}", postHandler.GetPostsByUserId)
+			v1.Use(middleware.RejectSuspended(s.db))
 			v1.Post("/posts", postHandler.CreatePost)
 			v1.Put("/posts/{id}", postHandler.UpdatePostById)
 			v1.Delete("/posts/{id}", postHandler.DeletePostById)
+			v1.Post("/posts/{id}/revisions/{revisionId}/revert", revisionHandler.RevertToRevision)
+			v1.Post("/posts/{id}/comments", commentHandler.CreateComment)
+			v1.Put("/posts/{id}/comments/{commentId}", commentHandler.UpdateComment)
+			v1.Delete("/posts/{id}/comments/{commentId}", commentHandler.DeleteComment)
+			v1.Put("/posts/{id}/reactions/{emoji}", reactionHandler.React)
+			v1.Delete("/posts/{id}/reactions/{emoji}", reactionHandler.Unreact)
+			v1.Put("/users/{userId}/follow", userHandler.Follow)
+			v1.Delete("/users/{userId}/follow", userHandler.Unfollow)
+			v1.Get("/timeline", postHandler.GetTimeline)
+			v1.Put("/posts/{id}/bookmark", bookmarkHandler.AddBookmark)
+			v1.Delete("/posts/{id}/bookmark", bookmarkHandler.RemoveBookmark)
+			v1.Get("/users/me/bookmarks", bookmarkHandler.GetBookmarks)
+			v1.Post("/media", mediaHandler.UploadMedia)
+			v1.Put("/posts/{id}/repost", repostHandler.Repost)
+			v1.Delete("/posts/{id}/repost", repostHandler.Unrepost)
+			v1.Get("/users/me/posts/export", exportHandler.ExportMyPosts)
+			v1.Post("/users/me/posts/import", importHandler.ImportPosts)
+			v1.Get("/federation/following", federationHandler.GetRemoteFollowing)
+			v1.Put("/federation/following", federationHandler.FollowRemote)
+			v1.Delete("/federation/following/{actorId}", federationHandler.UnfollowRemote)
+			v1.Get("/federation/timeline", federationHandler.GetRemoteTimeline)
+			v1.Post("/posts/{id}/reports", reportHandler.CreateReport)
+			v1.Get("/users/me/analytics", analyticsHandler.GetMyAnalytics)
+			v1.Put("/posts/{id}/pin", pinHandler.Pin)
+			v1.Delete("/posts/{id}/pin", pinHandler.Unpin)
+			v1.Put("/users/me/pins", pinHandler.ReorderPins)
+			v1.Post("/posts/{id}/poll/votes", pollHandler.Vote)
+			v1.Get("/notifications", notificationHandler.GetNotifications)
+			v1.Post("/notifications/read-all", notificationHandler.MarkAllRead)
+			v1.Post("/notifications/{notificationId}/read", notificationHandler.MarkRead)
+			v1.Get("/notifications/preferences", notificationHandler.GetPreferences)
+			v1.Put("/notifications/preferences", notificationHandler.UpdatePreferences)
+		})
+
+		// admin routes
+		v1.Route("/admin", func(admin chi.Router) {
+			admin.Use(middleware.VerifyAccessToken)
+			admin.Use(middleware.RejectSuspended(s.db))
+			admin.Use(middleware.RequireAdmin(s.db))
+			admin.Get("/posts/export", exportHandler.ExportAllPosts)
+		})
+
+		// moderator routes
+		v1.Route("/moderation", func(moderation chi.Router) {
+			moderation.Use(middleware.VerifyAccessToken)
+			moderation.Use(middleware.RejectSuspended(s.db))
+			moderation.Use(middleware.RequireModerator(s.db))
+			moderation.Get("/reports", reportHandler.GetQueue)
+			moderation.Post("/reports/{reportId}/claim", reportHandler.ClaimReport)
+			moderation.Post("/reports/{reportId}/resolve", reportHandler.ResolveReport)
 		})
 
 		// routes that need the refresh token
@@ -1,36 +1,66 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
 	"chi-mysql-boilerplate/internal/database"
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/filters"
+	"chi-mysql-boilerplate/internal/services"
+	"chi-mysql-boilerplate/internal/storage"
 )
 
 type Server struct {
-	port int
-	db   database.Db
+	port    int
+	db      database.Db
+	storage storage.Storage
+	filters *filters.Watcher
+	views   *services.ViewRecorder
+
+	httpServer *http.Server
+	// background workers started alongside the HTTP server
+	workers       sync.WaitGroup
+	stopWorkers   context.CancelFunc
+	workerContext context.Context
 }
 
-func NewServer() *http.Server {
+func NewServer() *Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	dbService, err := database.New()
 	if err != nil {
 		panic(fmt.Sprintf("Failed to initialize database: %v", err))
 	}
 
+	storageService, err := storage.New()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize storage: %v", err))
+	}
+
+	// unlike later reloads, a broken filter config at startup is fatal
+	filterWatcher := filters.NewWatcher(httpcommon.FilterConstants.ConfigPath, httpcommon.FilterConstants.ReloadInterval)
+	if err = filterWatcher.Load(); err != nil {
+		panic(fmt.Sprintf("Failed to load content filters: %v", err))
+	}
+
 	NewServer := &Server{
-		port: port,
-		db:   dbService,
+		port:    port,
+		db:      dbService,
+		storage: storageService,
+		filters: filterWatcher,
+		views:   services.NewViewRecorder(dbService),
 	}
+	NewServer.workerContext, NewServer.stopWorkers = context.WithCancel(context.Background())
 
 	// declare server config
-	server := &http.Server{
+	NewServer.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
 		Handler:      NewServer.RegisterRoutes(),
 		IdleTimeout:  time.Minute,
@@ -38,5 +68,47 @@ func NewServer() *http.Server {
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	return NewServer
+}
+
+func (s *Server) Addr() string {
+	return s.httpServer.Addr
+}
+
+// start the background workers, then serve HTTP until the server is shut down
+func (s *Server) ListenAndServe() error {
+	s.runInBackground(services.NewPublishScheduler(s.db).Run)
+	s.runInBackground(services.NewDeliveryWorker(s.db).Run)
+	s.runInBackground(services.NewLinkPreviewWorker(s.db).Run)
+	s.runInBackground(s.filters.Run)
+	s.runInBackground(s.views.Run)
+
+	return s.httpServer.ListenAndServe()
+}
+
+// stop accepting requests, then stop the background workers and wait for them to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.httpServer.Shutdown(ctx)
+
+	s.stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) runInBackground(worker func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		worker(s.workerContext)
+	}()
 }
@@ -0,0 +1,104 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AnalyticsService struct {
+	db *sql.DB
+}
+
+func NewAnalyticsService(db *sql.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+// daily views, reactions and new followers of a user between two days, both included.
+// views are written in batches, so the last few seconds of them may not show up yet
+func (as *AnalyticsService) GetUserAnalytics(userId uint64, from time.Time, to time.Time) (*models.AnalyticsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	// timestamps are compared against the start of the day after the range
+	end := to.AddDate(0, 0, 1)
+
+	query := `
+		SELECT post_view_days.day, SUM(post_view_days.views)
+		FROM post_view_days
+		JOIN posts ON post_view_days.post_id = posts.id
+		WHERE posts.user_id = ? AND post_view_days.day BETWEEN ? AND ?
+		GROUP BY post_view_days.day
+	`
+	views, err := queryDailyCounts(ctx, as.db, query, userId, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT DATE(post_reactions.created_at), COUNT(*)
+		FROM post_reactions
+		JOIN posts ON post_reactions.post_id = posts.id
+		WHERE posts.user_id = ? AND post_reactions.created_at >= ? AND post_reactions.created_at < ?
+		GROUP BY DATE(post_reactions.created_at)
+	`
+	reactions, err := queryDailyCounts(ctx, as.db, query, userId, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT DATE(created_at), COUNT(*)
+		FROM follows
+		WHERE followee_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY DATE(created_at)
+	`
+	followers, err := queryDailyCounts(ctx, as.db, query, userId, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &models.AnalyticsResponse{
+		From: from.Format(time.DateOnly),
+		To:   to.Format(time.DateOnly),
+		Days: []models.AnalyticsDay{},
+	}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		entry := models.AnalyticsDay{
+			Date:         date,
+			Views:        views[date],
+			Reactions:    reactions[date],
+			NewFollowers: followers[date],
+		}
+		analytics.Views += entry.Views
+		analytics.Reactions += entry.Reactions
+		analytics.NewFollowers += entry.NewFollowers
+		analytics.Days = append(analytics.Days, entry)
+	}
+
+	return analytics, nil
+}
+
+// helper function to run a query selecting a day and a count, keyed by YYYY-MM-DD
+func queryDailyCounts(ctx context.Context, db *sql.DB, query string, args ...interface{}) (map[string]uint64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]uint64)
+	for rows.Next() {
+		var day time.Time
+		var count uint64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		counts[day.Format(time.DateOnly)] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -62,7 +63,7 @@ func (a *AuthService) Login(req models.AuthRequest) (*models.AuthResponse, error
 	defer cancel()
 
 	query := `
-		SELECT id, password
+		SELECT id, password, suspended_at
 		FROM users
 		WHERE username = ?
 	`
@@ -70,7 +71,8 @@ func (a *AuthService) Login(req models.AuthRequest) (*models.AuthResponse, error
 
 	var res models.AuthResponse
 	var password string
-	if err := row.Scan(&res.ID, &password); err != nil {
+	var suspendedAt *time.Time
+	if err := row.Scan(&res.ID, &password, &suspendedAt); err != nil {
 		// if the user does not exist, return a bad credentials error
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New(httpcommon.ErrorMessage.ErrUserDoesNotExist)
@@ -83,6 +85,10 @@ func (a *AuthService) Login(req models.AuthRequest) (*models.AuthResponse, error
 	if !IsCorrectPassword(req.Password, password) {
 		return nil, errors.New(httpcommon.ErrorMessage.BadCredentials)
 	}
+	// only told after the password checks out, so it doesn't reveal who's suspended
+	if suspendedAt != nil {
+		return nil, errors.New(httpcommon.ErrorMessage.AccountSuspended)
+	}
 
 	return &res, nil
 }
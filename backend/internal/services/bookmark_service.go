@@ -0,0 +1,153 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type BookmarkService struct {
+	db          *sql.DB
+	postService *PostService
+}
+
+func NewBookmarkService(db *sql.DB) *BookmarkService {
+	return &BookmarkService{db: db, postService: NewPostService(db)}
+}
+
+// bookmarking a post twice is a no-op thanks to the unique key,
+// bookmarks go away with the post through the post_id foreign key
+func (b *BookmarkService) Add(userId uint64, postId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	// make sure the post exists before bookmarking it
+	var exists int
+	if err := b.db.QueryRowContext(ctx, "SELECT 1 FROM posts WHERE id = ?", postId).Scan(&exists); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT IGNORE INTO bookmarks (user_id, post_id, created_at)
+		VALUES (?, ?, ?)
+	`
+	_, err := b.db.ExecContext(ctx, query, userId, postId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (b *BookmarkService) Remove(userId uint64, postId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM bookmarks
+		WHERE user_id = ? AND post_id = ?
+	`
+	_, err := b.db.ExecContext(ctx, query, userId, postId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fetch a page of the user's bookmarked posts, most recently bookmarked first
+// the cursor is the ID of the bookmark rather than of the post
+func (b *BookmarkService) GetByUserId(userId uint64, page models.PageRequest) (*models.Page[*models.PostResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, post_id
+		FROM bookmarks
+		WHERE user_id = ? AND (? = 0 OR id < ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	// grab one extra row to know if there's a next page
+	rows, err := b.db.QueryContext(ctx, query, userId, page.Cursor, page.Cursor, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookmarkIds, postIds []uint64
+	for rows.Next() {
+		var bookmarkId, postId uint64
+		if err := rows.Scan(&bookmarkId, &postId); err != nil {
+			return nil, err
+		}
+		bookmarkIds = append(bookmarkIds, bookmarkId)
+		postIds = append(postIds, postId)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	result := &models.Page[*models.PostResponse]{Items: []*models.PostResponse{}}
+	if len(postIds) > page.Limit {
+		postIds = postIds[:page.Limit]
+		nextCursor := bookmarkIds[page.Limit-1]
+		result.NextCursor = &nextCursor
+	}
+	if len(postIds) == 0 {
+		return result, nil
+	}
+
+	// posts moved back to drafts or restricted since being bookmarked drop out of the list
+	visible, visibleArgs := visibleTo(userId)
+	query = fmt.Sprintf(`
+		SELECT %s
+		FROM posts JOIN users ON posts.user_id = users.id
+		WHERE posts.id IN (%s) AND %s
+	`, postResponseColumns, placeholders(len(postIds)), visible)
+	args := append(idsToArgs(postIds), visibleArgs...)
+	posts, err := b.postService.queryPosts(ctx, userId, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// put the posts back in bookmark order
+	byId := make(map[uint64]*models.PostResponse, len(posts))
+	for _, post := range posts {
+		byId[post.ID] = post
+	}
+	for _, postId := range postIds {
+		if post, ok := byId[postId]; ok {
+			result.Items = append(result.Items, post)
+		}
+	}
+
+	return result, nil
+}
+
+// find which of a batch of posts the viewer has bookmarked
+func loadBookmarkedPostIds(ctx context.Context, db *sql.DB, postIds []uint64, viewerId uint64) (map[uint64]bool, error) {
+	bookmarked := make(map[uint64]bool)
+	if len(postIds) == 0 || viewerId == 0 {
+		return bookmarked, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT post_id
+		FROM bookmarks
+		WHERE user_id = ? AND post_id IN (%s)
+	`, placeholders(len(postIds)))
+	ids, err := queryIds(ctx, db, query, append([]interface{}{viewerId}, idsToArgs(postIds)...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+
+	return bookmarked, nil
+}
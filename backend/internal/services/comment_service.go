@@ -0,0 +1,262 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type CommentService struct {
+	db *sql.DB
+}
+
+func NewCommentService(db *sql.DB) *CommentService {
+	return &CommentService{db: db}
+}
+
+const commentResponseColumns = `
+	comments.id, comments.post_id, comments.parent_id, comments.user_id, users.username,
+	comments.depth, comments.content, comments.created_at, comments.updated_at
+`
+
+func (c *CommentService) Create(postId uint64, userId uint64, req models.CommentRequest) (*models.CommentResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	// the post's author and the author of the comment being replied to get notified
+	recipientIds := []uint64{}
+	var rootId *uint64
+	depth := 0
+	if req.ParentID != nil {
+		// replies inherit the thread's root and sit one level below their parent
+		query := `
+			SELECT post_id, root_id, depth, user_id
+			FROM comments
+			WHERE id = ?
+		`
+		var parentPostId, parentAuthorId uint64
+		var parentRootId sql.NullInt64
+		var parentDepth int
+		err := c.db.QueryRowContext(ctx, query, *req.ParentID).Scan(&parentPostId, &parentRootId, &parentDepth, &parentAuthorId)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && parentPostId != postId) {
+			return nil, errors.New(httpcommon.ErrorMessage.InvalidParentComment)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if parentDepth+1 > httpcommon.CommentConstants.MaxDepth {
+			return nil, errors.New(httpcommon.ErrorMessage.CommentTooDeep)
+		}
+
+		depth = parentDepth + 1
+		if parentRootId.Valid {
+			root := uint64(parentRootId.Int64)
+			rootId = &root
+		} else {
+			rootId = req.ParentID
+		}
+		recipientIds = append(recipientIds, parentAuthorId)
+	}
+
+	// make sure the post exists before commenting on it
+	var postAuthorId uint64
+	if err := c.db.QueryRowContext(ctx, "SELECT user_id FROM posts WHERE id = ?", postId).Scan(&postAuthorId); err != nil {
+		return nil, err
+	}
+	recipientIds = append(recipientIds, postAuthorId)
+
+	query := `
+		INSERT INTO comments (post_id, user_id, parent_id, root_id, depth, content, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	creationTime := time.Now()
+	result, err := c.db.ExecContext(ctx, query, postId, userId, req.ParentID, rootId, depth, req.Content, creationTime, creationTime)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	commentId := uint64(id)
+	err = createNotifications(ctx, c.db, models.Notification{
+		Kind:      httpcommon.NotificationKind.Reply,
+		ActorID:   userId,
+		PostID:    &postId,
+		CommentID: &commentId,
+	}, recipientIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetById(commentId)
+}
+
+// fetch a page of top-level comments on a post, each with its whole reply thread attached
+func (c *CommentService) GetByPostId(postId uint64, page models.PageRequest) (*models.Page[*models.CommentResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM comments JOIN users ON comments.user_id = users.id
+		WHERE comments.post_id = ? AND comments.parent_id IS NULL AND comments.id > ?
+		ORDER BY comments.id
+		LIMIT ?
+	`, commentResponseColumns)
+	// grab one extra row to know if there's a next page
+	roots, err := c.queryComments(ctx, query, postId, page.Cursor, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[*models.CommentResponse]{Items: roots}
+	if len(roots) > page.Limit {
+		result.Items = roots[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+	if len(result.Items) == 0 {
+		return result, nil
+	}
+
+	rootIds := make([]uint64, len(result.Items))
+	byId := make(map[uint64]*models.CommentResponse, len(result.Items))
+	for i, root := range result.Items {
+		rootIds[i] = root.ID
+		byId[root.ID] = root
+	}
+
+	query = fmt.Sprintf(`
+		SELECT %s
+		FROM comments JOIN users ON comments.user_id = users.id
+		WHERE comments.root_id IN (%s)
+		ORDER BY comments.id
+	`, commentResponseColumns, placeholders(len(rootIds)))
+	replies, err := c.queryComments(ctx, query, idsToArgs(rootIds)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// replies are ordered by ID, so a parent is always seen before its children
+	for _, reply := range replies {
+		byId[reply.ID] = reply
+		if parent, ok := byId[*reply.ParentID]; ok {
+			parent.Replies = append(parent.Replies, reply)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *CommentService) GetById(id uint64) (*models.CommentResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM comments JOIN users ON comments.user_id = users.id
+		WHERE comments.id = ?
+	`, commentResponseColumns)
+	comments, err := c.queryComments(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return comments[0], nil
+}
+
+func (c *CommentService) UpdateById(id uint64, updateContent string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		UPDATE comments
+		SET
+			content = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+	_, err := c.db.ExecContext(ctx, query, updateContent, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// replies are removed along with the comment through the parent_id foreign key
+func (c *CommentService) DeleteById(id uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM comments
+		WHERE id = ?
+	`
+	_, err := c.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// a comment can be modified by whoever wrote it or by the author of the post it's on
+func (c *CommentService) CanModify(postId uint64, commentId uint64, userId uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT comments.user_id, posts.user_id
+		FROM comments JOIN posts ON comments.post_id = posts.id
+		WHERE comments.id = ? AND comments.post_id = ?
+	`
+	var commentAuthorId, postAuthorId uint64
+	if err := c.db.QueryRowContext(ctx, query, commentId, postId).Scan(&commentAuthorId, &postAuthorId); err != nil {
+		return false, err
+	}
+
+	return userId == commentAuthorId || userId == postAuthorId, nil
+}
+
+func (c *CommentService) queryComments(ctx context.Context, query string, args ...interface{}) ([]*models.CommentResponse, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.CommentResponse{}
+	for rows.Next() {
+		var comment models.CommentResponse
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.ParentID,
+			&comment.UserID,
+			&comment.UserName,
+			&comment.Depth,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		comment.Replies = []*models.CommentResponse{}
+		comments = append(comments, &comment)
+	}
+
+	return comments, rows.Err()
+}
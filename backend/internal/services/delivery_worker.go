@@ -0,0 +1,243 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/activitypub"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// how many deliveries of a batch are in flight at once
+const deliveryConcurrency = 8
+
+type DeliveryWorker struct {
+	db *sql.DB
+}
+
+func NewDeliveryWorker(db *sql.DB) *DeliveryWorker {
+	return &DeliveryWorker{db: db}
+}
+
+type deliveryJob struct {
+	id       uint64
+	userId   uint64
+	inbox    string
+	activity []byte
+	attempts int
+}
+
+// deliver due activities on every tick until the context is cancelled
+func (dw *DeliveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(httpcommon.FederationConstants.DeliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			claimed, err := dw.DeliverDue(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					helpers.MessageLogs.ErrorLog.Println(err)
+				}
+				break
+			}
+			if claimed < httpcommon.FederationConstants.DeliveryBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim a batch of due deliveries and attempt them, returns how many were claimed.
+// claiming pushes the next attempt back by the lease instead of holding the row locks
+// during the requests, so a worker that dies mid-batch only delays its jobs
+func (dw *DeliveryWorker) DeliverDue(ctx context.Context) (int, error) {
+	jobs, err := dw.claimJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make(map[uint64]*signingKey)
+	for _, job := range jobs {
+		if _, ok := keys[job.userId]; ok {
+			continue
+		}
+		key, err := loadSigningKey(ctx, dw.db, job.userId)
+		if err != nil {
+			return 0, err
+		}
+		keys[job.userId] = key
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, deliveryConcurrency)
+	for _, job := range jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(job deliveryJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			key := keys[job.userId]
+			err := activitypub.Deliver(ctx, job.inbox, job.activity, key.id, key.privateKey)
+			if ctx.Err() != nil {
+				// shutting down, the lease runs out and the job is picked up again
+				return
+			}
+			if err = dw.recordAttempt(job, err); err != nil {
+				helpers.MessageLogs.ErrorLog.Println(err)
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+func (dw *DeliveryWorker) claimJobs(ctx context.Context) ([]deliveryJob, error) {
+	tx, err := dw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, user_id, inbox_uri, activity, attempts
+		FROM delivery_jobs
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?
+		FOR UPDATE
+	`
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, query, httpcommon.DeliveryStatus.Pending, now, httpcommon.FederationConstants.DeliveryBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []deliveryJob
+	var ids []uint64
+	for rows.Next() {
+		var job deliveryJob
+		if err := rows.Scan(&job.id, &job.userId, &job.inbox, &job.activity, &job.attempts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, job.id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	query = fmt.Sprintf("UPDATE delivery_jobs SET next_attempt_at = ? WHERE id IN (%s)", placeholders(len(ids)))
+	args := append([]interface{}{now.Add(httpcommon.FederationConstants.DeliveryLease)}, idsToArgs(ids)...)
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// delivered jobs are removed, failed ones are retried with exponential backoff
+// until they run out of attempts, or right away given up on if the inbox is gone for good
+func (dw *DeliveryWorker) recordAttempt(job deliveryJob, deliveryErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	if deliveryErr == nil {
+		_, err := dw.db.ExecContext(ctx, "DELETE FROM delivery_jobs WHERE id = ?", job.id)
+		return err
+	}
+
+	attempts := job.attempts + 1
+	status := httpcommon.DeliveryStatus.Pending
+	var statusErr *activitypub.StatusError
+	gone := errors.As(deliveryErr, &statusErr) && statusErr.StatusCode == http.StatusGone
+	if gone || attempts >= httpcommon.FederationConstants.DeliveryMaxAttempts {
+		status = httpcommon.DeliveryStatus.Failed
+	}
+	nextAttemptAt := time.Now().Add(httpcommon.FederationConstants.DeliveryRetryDelay << min(attempts-1, 16))
+
+	query := `
+		UPDATE delivery_jobs
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?
+		WHERE id = ?
+	`
+	_, err := dw.db.ExecContext(ctx, query, status, attempts, nextAttemptAt, deliveryErr.Error(), job.id)
+
+	return err
+}
+
+// queue an activity for delivery to a single inbox
+func queueDelivery(ctx context.Context, db dbExecutor, userId uint64, inbox string, activity interface{}) error {
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO delivery_jobs (user_id, inbox_uri, activity, status, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = db.ExecContext(ctx, query, userId, inbox, payload, httpcommon.DeliveryStatus.Pending, time.Now())
+
+	return err
+}
+
+// queue an activity for every instance with followers of the user,
+// once per shared inbox rather than once per follower where the instance has one
+func queueForRemoteFollowers(ctx context.Context, db dbExecutor, userId uint64, activity interface{}) error {
+	query := `
+		SELECT DISTINCT COALESCE(remote_actors.shared_inbox_uri, remote_actors.inbox_uri)
+		FROM remote_followers
+		JOIN remote_actors ON remote_followers.remote_actor_id = remote_actors.id
+		WHERE remote_followers.user_id = ?
+	`
+	rows, err := db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+
+	var inboxes []string
+	for rows.Next() {
+		var inbox string
+		if err := rows.Scan(&inbox); err != nil {
+			rows.Close()
+			return err
+		}
+		inboxes = append(inboxes, inbox)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, inbox := range inboxes {
+		if err = queueDelivery(ctx, db, userId, inbox, activity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type ExportService struct {
+	db *sql.DB
+}
+
+func NewExportService(db *sql.DB) *ExportService {
+	return &ExportService{db: db}
+}
+
+// hand every post by a user (or everyone's when userId is 0) to fn one row at a time, oldest first,
+// drafts and restricted posts included since exports are for the author or an admin.
+// the rows are read as they're written out, so the caller's context rather than
+// the usual query timeout bounds how long this takes
+func (e *ExportService) StreamPosts(ctx context.Context, userId uint64, fn func(post models.ExportedPost) error) error {
+	query := `
+		SELECT
+			posts.id, posts.user_id, users.username, posts.content, posts.status, posts.visibility,
+			posts.publish_at, posts.repost_of_id, posts.quote_of_id, posts.created_at, posts.updated_at,
+			(
+				SELECT GROUP_CONCAT(tags.name ORDER BY tags.name SEPARATOR ' ')
+				FROM post_tags JOIN tags ON post_tags.tag_id = tags.id
+				WHERE post_tags.post_id = posts.id
+			) AS tags
+		FROM posts JOIN users ON posts.user_id = users.id
+		WHERE ? = 0 OR posts.user_id = ?
+		ORDER BY posts.id
+	`
+	rows, err := e.db.QueryContext(ctx, query, userId, userId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post models.ExportedPost
+		var tags sql.NullString
+		if err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.UserName,
+			&post.Content,
+			&post.Status,
+			&post.Visibility,
+			&post.PublishAt,
+			&post.RepostOfID,
+			&post.QuoteOfID,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&tags,
+		); err != nil {
+			return err
+		}
+
+		// tag names never contain spaces, so that's a safe separator
+		post.Tags = []string{}
+		if tags.String != "" {
+			post.Tags = strings.Split(tags.String, " ")
+		}
+
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,1016 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/utils/activitypub"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/markdown"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type FederationService struct {
+	db *sql.DB
+}
+
+func NewFederationService(db *sql.DB) *FederationService {
+	return &FederationService{db: db}
+}
+
+// a cached actor from another instance
+type remoteActor struct {
+	id           uint64
+	uri          string
+	username     string
+	inbox        string
+	keyId        string
+	publicKeyPem string
+}
+
+type signingKey struct {
+	id         string
+	privateKey *rsa.PrivateKey
+}
+
+// resolve a WebFinger resource, either acct:user@domain or the URI of an actor, to its actor
+func (f *FederationService) WebFinger(resource string) (*activitypub.WebFinger, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var username string
+	if handle, ok := strings.CutPrefix(resource, "acct:"); ok {
+		name, domain, ok := strings.Cut(handle, "@")
+		if !ok || !strings.EqualFold(domain, httpcommon.FederationConstants.Domain) {
+			return nil, sql.ErrNoRows
+		}
+		username = name
+	} else {
+		var ok bool
+		if username, ok = usernameFromActorURI(resource); !ok {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	if err := f.db.QueryRowContext(ctx, "SELECT username FROM users WHERE username = ?", username).Scan(&username); err != nil {
+		return nil, err
+	}
+
+	return &activitypub.WebFinger{
+		Subject: fmt.Sprintf("acct:%s@%s", username, httpcommon.FederationConstants.Domain),
+		Aliases: []string{actorURI(username)},
+		Links: []activitypub.WebFingerLink{
+			{Rel: "self", Type: activitypub.ContentType, Href: actorURI(username)},
+		},
+	}, nil
+}
+
+// build the actor document of a user, creating their key pair the first time
+func (f *FederationService) GetActor(username string) (*activitypub.Actor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var userId uint64
+	if err := f.db.QueryRowContext(ctx, "SELECT id, username FROM users WHERE username = ?", username).Scan(&userId, &username); err != nil {
+		return nil, err
+	}
+
+	publicKeyPem, _, err := ensureActorKey(ctx, f.db, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	id := actorURI(username)
+	return &activitypub.Actor{
+		Context:           activitypub.ActorContext,
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: username,
+		Name:              username,
+		URL:               fmt.Sprintf("%s/users/%d", httpcommon.FeedConstants.SiteURL, userId),
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		Endpoints:         &activitypub.Endpoints{SharedInbox: httpcommon.FederationConstants.BaseURL + "/ap/inbox"},
+		PublicKey: activitypub.PublicKey{
+			ID:           id + "#main-key",
+			Owner:        id,
+			PublicKeyPem: publicKeyPem,
+		},
+	}, nil
+}
+
+// the outbox lists a user's public posts as Create activities, newest first.
+// without a page only the size of the collection and a link to its first page are returned
+func (f *FederationService) GetOutbox(username string, page *models.PageRequest) (*activitypub.OrderedCollection, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var userId uint64
+	if err := f.db.QueryRowContext(ctx, "SELECT id, username FROM users WHERE username = ?", username).Scan(&userId, &username); err != nil {
+		return nil, err
+	}
+
+	outbox := actorURI(username) + "/outbox"
+	if page == nil {
+		var total uint64
+		query := `
+			SELECT COUNT(*)
+			FROM posts
+			WHERE user_id = ? AND status = ? AND visibility = ? AND repost_of_id IS NULL AND hidden_at IS NULL
+		`
+		if err := f.db.QueryRowContext(ctx, query, userId, httpcommon.PostStatus.Published, httpcommon.PostVisibility.Public).Scan(&total); err != nil {
+			return nil, err
+		}
+
+		return &activitypub.OrderedCollection{
+			Context:    activitypub.ObjectContext,
+			ID:         outbox,
+			Type:       "OrderedCollection",
+			TotalItems: total,
+			First:      outbox + "?page=true",
+		}, nil
+	}
+
+	query := `
+		SELECT id
+		FROM posts
+		WHERE user_id = ? AND status = ? AND visibility = ? AND repost_of_id IS NULL AND hidden_at IS NULL AND (? = 0 OR id < ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	// grab one extra row to know if there's a next page
+	postIds, err := queryIds(ctx, f.db, query, userId, httpcommon.PostStatus.Published, httpcommon.PostVisibility.Public, page.Cursor, page.Cursor, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionPage := &activitypub.OrderedCollection{
+		Context:      activitypub.ObjectContext,
+		ID:           fmt.Sprintf("%s?page=true&cursor=%d", outbox, page.Cursor),
+		Type:         "OrderedCollectionPage",
+		PartOf:       outbox,
+		OrderedItems: []interface{}{},
+	}
+	if len(postIds) > page.Limit {
+		postIds = postIds[:page.Limit]
+		collectionPage.Next = fmt.Sprintf("%s?page=true&cursor=%d", outbox, postIds[page.Limit-1])
+	}
+	for _, postId := range postIds {
+		note, _, err := loadFederatedNote(ctx, f.db, postId)
+		// the post may have been deleted or hidden since it was listed
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && note == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		collectionPage.OrderedItems = append(collectionPage.OrderedItems, createActivity(note))
+	}
+
+	return collectionPage, nil
+}
+
+// the followers collection only tells how many followers a user has, local and remote
+func (f *FederationService) GetFollowers(username string) (*activitypub.OrderedCollection, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			username,
+			(SELECT COUNT(*) FROM follows WHERE followee_id = users.id) +
+			(SELECT COUNT(*) FROM remote_followers WHERE user_id = users.id)
+		FROM users
+		WHERE username = ?
+	`
+	var total uint64
+	if err := f.db.QueryRowContext(ctx, query, username).Scan(&username, &total); err != nil {
+		return nil, err
+	}
+
+	return &activitypub.OrderedCollection{
+		Context:    activitypub.ObjectContext,
+		ID:         actorURI(username) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: total,
+	}, nil
+}
+
+// only public posts can be fetched, the others are delivered to their audience directly
+func (f *FederationService) GetNote(postId uint64) (*activitypub.Note, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	note, _, err := loadFederatedNote(ctx, f.db, postId)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil || !slices.Contains(note.To, activitypub.PublicCollection) {
+		return nil, sql.ErrNoRows
+	}
+
+	note.Context = activitypub.ObjectContext
+	return note, nil
+}
+
+// handle an activity posted to an inbox, after checking that it was signed by its actor.
+// activities we don't act on are accepted and dropped
+func (f *FederationService) ReceiveActivity(req *http.Request, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout+httpcommon.FederationConstants.RequestTimeout)
+	defer cancel()
+
+	signature, err := activitypub.ParseSignature(req)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return errors.New(httpcommon.ErrorMessage.InvalidSignature)
+	}
+
+	var activity activitypub.IncomingActivity
+	if err = json.Unmarshal(body, &activity); err != nil || activity.Type == "" {
+		return errors.New(httpcommon.ErrorMessage.InvalidActivity)
+	}
+	actorId := activitypub.RefID(activity.Actor)
+
+	// deleted accounts announce it to every instance they ever saw, there's nothing
+	// to clean up for actors we don't know and their keys can't be fetched anymore
+	if activity.Type == "Delete" && activitypub.RefID(activity.Object) == actorId {
+		if _, err = findRemoteActor(ctx, f.db, "actor_uri", actorId); errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+	}
+
+	actor, err := f.verifySigner(ctx, req, body, signature)
+	if err != nil {
+		return err
+	}
+	// forwarded activities signed by someone else than their actor aren't supported
+	if actor.uri != actorId {
+		return errors.New(httpcommon.ErrorMessage.InvalidSignature)
+	}
+
+	switch activity.Type {
+	case "Follow":
+		return f.receiveFollow(ctx, actor, activity)
+	case "Undo":
+		return f.receiveUndo(ctx, actor, activity)
+	case "Accept", "Reject":
+		return f.receiveFollowResponse(ctx, actor, activity)
+	case "Create", "Update":
+		return f.receiveNote(ctx, actor, activity)
+	case "Delete":
+		return f.receiveDelete(ctx, actor, activity)
+	}
+
+	return nil
+}
+
+// follow a user on another instance, the follow stays pending until they accept it
+func (f *FederationService) FollowRemote(userId uint64, handle string) (*models.RemoteActorResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout+2*httpcommon.FederationConstants.RequestTimeout)
+	defer cancel()
+
+	actorId, err := activitypub.LookupWebFinger(ctx, handle)
+	if errors.Is(err, activitypub.ErrInvalidURL) {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidHandle)
+	}
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return nil, errors.New(httpcommon.ErrorMessage.RemoteActorNotFound)
+	}
+	actor, err := f.refreshActor(ctx, actorId)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return nil, errors.New(httpcommon.ErrorMessage.RemoteActorNotFound)
+	}
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var username string
+	if err = tx.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", userId).Scan(&username); err != nil {
+		return nil, err
+	}
+
+	// following again resends the original Follow, in case the first one got lost
+	query := `
+		INSERT IGNORE INTO remote_following (user_id, remote_actor_id, follow_activity_uri)
+		VALUES (?, ?, ?)
+	`
+	if _, err = tx.ExecContext(ctx, query, userId, actor.id, newActivityURI()); err != nil {
+		return nil, err
+	}
+
+	response := models.RemoteActorResponse{ID: actor.id, ActorURI: actor.uri, Handle: remoteHandle(actor.username, actor.uri)}
+	var followId string
+	query = "SELECT follow_activity_uri, accepted, created_at FROM remote_following WHERE user_id = ? AND remote_actor_id = ?"
+	if err = tx.QueryRowContext(ctx, query, userId, actor.id).Scan(&followId, &response.Accepted, &response.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	follow := activitypub.Activity{
+		Context: activitypub.ObjectContext,
+		ID:      followId,
+		Type:    "Follow",
+		Actor:   actorURI(username),
+		Object:  actor.uri,
+	}
+	if err = queueDelivery(ctx, tx, userId, actor.inbox, follow); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// stop following a user on another instance, remoteActorId being the ID returned when following
+func (f *FederationService) UnfollowRemote(userId uint64, remoteActorId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT users.username, remote_following.follow_activity_uri, remote_actors.actor_uri, remote_actors.inbox_uri
+		FROM remote_following
+		JOIN users ON remote_following.user_id = users.id
+		JOIN remote_actors ON remote_following.remote_actor_id = remote_actors.id
+		WHERE remote_following.user_id = ? AND remote_following.remote_actor_id = ?
+		FOR UPDATE
+	`
+	var username, followId, remoteActorURI, inbox string
+	err = tx.QueryRowContext(ctx, query, userId, remoteActorId).Scan(&username, &followId, &remoteActorURI, &inbox)
+	// unfollowing someone you don't follow is a no-op
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM remote_following WHERE user_id = ? AND remote_actor_id = ?", userId, remoteActorId); err != nil {
+		return err
+	}
+
+	undo := activitypub.Activity{
+		Context: activitypub.ObjectContext,
+		ID:      newActivityURI(),
+		Type:    "Undo",
+		Actor:   actorURI(username),
+		Object: activitypub.Activity{
+			ID:     followId,
+			Type:   "Follow",
+			Actor:  actorURI(username),
+			Object: remoteActorURI,
+		},
+	}
+	if err = queueDelivery(ctx, tx, userId, inbox, undo); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (f *FederationService) GetRemoteFollowing(userId uint64) ([]*models.RemoteActorResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT remote_actors.id, remote_actors.actor_uri, remote_actors.username, remote_following.accepted, remote_following.created_at
+		FROM remote_following
+		JOIN remote_actors ON remote_following.remote_actor_id = remote_actors.id
+		WHERE remote_following.user_id = ?
+		ORDER BY remote_following.id DESC
+	`
+	rows, err := f.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	following := []*models.RemoteActorResponse{}
+	for rows.Next() {
+		var actor models.RemoteActorResponse
+		var username string
+		if err := rows.Scan(&actor.ID, &actor.ActorURI, &username, &actor.Accepted, &actor.CreatedAt); err != nil {
+			return nil, err
+		}
+		actor.Handle = remoteHandle(username, actor.ActorURI)
+		following = append(following, &actor)
+	}
+
+	return following, rows.Err()
+}
+
+// fetch a page of the posts received from the remote users someone follows, newest first
+func (f *FederationService) GetRemoteTimeline(userId uint64, page models.PageRequest) (*models.Page[*models.RemotePostResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			remote_posts.id,
+			remote_posts.object_uri,
+			remote_posts.url,
+			remote_posts.content_html,
+			remote_actors.id,
+			remote_actors.actor_uri,
+			remote_actors.username,
+			remote_posts.published_at,
+			remote_posts.created_at
+		FROM remote_posts
+		JOIN remote_actors ON remote_posts.remote_actor_id = remote_actors.id
+		JOIN remote_following ON remote_following.remote_actor_id = remote_actors.id
+		WHERE remote_following.user_id = ? AND remote_following.accepted = TRUE AND (? = 0 OR remote_posts.id < ?)
+		ORDER BY remote_posts.id DESC
+		LIMIT ?
+	`
+	// grab one extra row to know if there's a next page
+	rows, err := f.db.QueryContext(ctx, query, userId, page.Cursor, page.Cursor, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &models.Page[*models.RemotePostResponse]{Items: []*models.RemotePostResponse{}}
+	for rows.Next() {
+		var post models.RemotePostResponse
+		var username string
+		if err := rows.Scan(
+			&post.ID,
+			&post.ObjectURI,
+			&post.URL,
+			&post.ContentHTML,
+			&post.ActorID,
+			&post.ActorURI,
+			&username,
+			&post.PublishedAt,
+			&post.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		post.Handle = remoteHandle(username, post.ActorURI)
+		result.Items = append(result.Items, &post)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) > page.Limit {
+		result.Items = result.Items[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+
+	return result, nil
+}
+
+// a remote actor follows a local user, which is accepted right away
+func (f *FederationService) receiveFollow(ctx context.Context, actor *remoteActor, activity activitypub.IncomingActivity) error {
+	target := activitypub.RefID(activity.Object)
+	username, ok := usernameFromActorURI(target)
+	if !ok || activity.ID == "" {
+		return errors.New(httpcommon.ErrorMessage.InvalidActivity)
+	}
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userId uint64
+	if err = tx.QueryRowContext(ctx, "SELECT id, username FROM users WHERE username = ?", username).Scan(&userId, &username); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO remote_followers (user_id, remote_actor_id, follow_activity_uri)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE follow_activity_uri = VALUES(follow_activity_uri)
+	`
+	if _, err = tx.ExecContext(ctx, query, userId, actor.id, activity.ID); err != nil {
+		return err
+	}
+
+	accept := activitypub.Activity{
+		Context: activitypub.ObjectContext,
+		ID:      newActivityURI(),
+		Type:    "Accept",
+		Actor:   actorURI(username),
+		Object: activitypub.Activity{
+			ID:     activity.ID,
+			Type:   "Follow",
+			Actor:  actor.uri,
+			Object: actorURI(username),
+		},
+	}
+	if err = queueDelivery(ctx, tx, userId, actor.inbox, accept); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// only undoing a follow means anything to us
+func (f *FederationService) receiveUndo(ctx context.Context, actor *remoteActor, activity activitypub.IncomingActivity) error {
+	result, err := f.db.ExecContext(
+		ctx,
+		"DELETE FROM remote_followers WHERE remote_actor_id = ? AND follow_activity_uri = ?",
+		actor.id,
+		activitypub.RefID(activity.Object),
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+
+	// some implementations mint a new ID for the Follow they undo, so fall back to its target
+	if activitypub.RefType(activity.Object) != "Follow" {
+		return nil
+	}
+	var follow activitypub.IncomingActivity
+	if err = json.Unmarshal(activity.Object, &follow); err != nil {
+		return errors.New(httpcommon.ErrorMessage.InvalidActivity)
+	}
+	username, ok := usernameFromActorURI(activitypub.RefID(follow.Object))
+	if !ok {
+		return nil
+	}
+
+	query := `
+		DELETE remote_followers
+		FROM remote_followers JOIN users ON remote_followers.user_id = users.id
+		WHERE remote_followers.remote_actor_id = ? AND users.username = ?
+	`
+	_, err = f.db.ExecContext(ctx, query, actor.id, username)
+
+	return err
+}
+
+// a remote actor answers a follow request sent by a local user
+func (f *FederationService) receiveFollowResponse(ctx context.Context, actor *remoteActor, activity activitypub.IncomingActivity) error {
+	followId := activitypub.RefID(activity.Object)
+
+	var err error
+	if activity.Type == "Accept" {
+		_, err = f.db.ExecContext(
+			ctx,
+			"UPDATE remote_following SET accepted = TRUE WHERE remote_actor_id = ? AND follow_activity_uri = ?",
+			actor.id,
+			followId,
+		)
+	} else {
+		_, err = f.db.ExecContext(
+			ctx,
+			"DELETE FROM remote_following WHERE remote_actor_id = ? AND follow_activity_uri = ?",
+			actor.id,
+			followId,
+		)
+	}
+
+	return err
+}
+
+// store a note, or the new version of one, from an actor that someone here follows
+func (f *FederationService) receiveNote(ctx context.Context, actor *remoteActor, activity activitypub.IncomingActivity) error {
+	if activitypub.RefType(activity.Object) != "Note" {
+		return nil
+	}
+	var note activitypub.Note
+	if err := json.Unmarshal(activity.Object, &note); err != nil {
+		return errors.New(httpcommon.ErrorMessage.InvalidActivity)
+	}
+	if note.AttributedTo != actor.uri || !sameHost(note.ID, actor.uri) {
+		return errors.New(httpcommon.ErrorMessage.InvalidActivity)
+	}
+
+	var followed bool
+	if err := f.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM remote_following WHERE remote_actor_id = ?)", actor.id).Scan(&followed); err != nil {
+		return err
+	}
+	if !followed {
+		return nil
+	}
+
+	var noteURL *string
+	if note.URL != "" && markdown.IsSafeURL(note.URL) {
+		noteURL = &note.URL
+	}
+	contentHtml := markdown.Sanitize(note.Content)
+
+	// the actor check keeps one account from overwriting the notes of another on the same host
+	query := `
+		UPDATE remote_posts
+		SET content_html = ?, url = ?
+		WHERE object_uri = ? AND remote_actor_id = ?
+	`
+	result, err := f.db.ExecContext(ctx, query, contentHtml, noteURL, note.ID, actor.id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+
+	query = `
+		INSERT IGNORE INTO remote_posts (remote_actor_id, object_uri, content_html, url, published_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = f.db.ExecContext(ctx, query, actor.id, note.ID, contentHtml, noteURL, note.Published)
+
+	return err
+}
+
+// a remote actor deletes one of their notes, or their whole account
+func (f *FederationService) receiveDelete(ctx context.Context, actor *remoteActor, activity activitypub.IncomingActivity) error {
+	objectId := activitypub.RefID(activity.Object)
+
+	var err error
+	if objectId == actor.uri {
+		// follows and notes go with the actor
+		_, err = f.db.ExecContext(ctx, "DELETE FROM remote_actors WHERE id = ?", actor.id)
+	} else {
+		_, err = f.db.ExecContext(ctx, "DELETE FROM remote_posts WHERE object_uri = ? AND remote_actor_id = ?", objectId, actor.id)
+	}
+
+	return err
+}
+
+// find who signed a request and check the signature, fetching the signer's actor
+// when the key isn't known yet or doesn't match anymore because it was rotated
+func (f *FederationService) verifySigner(ctx context.Context, req *http.Request, body []byte, signature *activitypub.Signature) (*remoteActor, error) {
+	maxAge := httpcommon.FederationConstants.SignatureMaxAge
+
+	actor, err := findRemoteActor(ctx, f.db, "key_id", signature.KeyID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil {
+		if key, err := activitypub.ParsePublicKey(actor.publicKeyPem); err == nil {
+			if err = activitypub.Verify(req, body, signature, key, maxAge); err == nil {
+				return actor, nil
+			}
+		}
+	}
+
+	// nothing fetched here is stored until the signature checks out, so unsigned
+	// requests can't fill remote_actors with whatever their key IDs point at
+	keyOwner, _, _ := strings.Cut(signature.KeyID, "#")
+	document, err := fetchActor(ctx, keyOwner)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidSignature)
+	}
+	if document.PublicKey.ID != signature.KeyID {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidSignature)
+	}
+
+	key, err := activitypub.ParsePublicKey(document.PublicKey.PublicKeyPem)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidSignature)
+	}
+	if err = activitypub.Verify(req, body, signature, key, maxAge); err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidSignature)
+	}
+
+	return f.saveActor(ctx, document)
+}
+
+// fetch an actor document and cache it
+func (f *FederationService) refreshActor(ctx context.Context, uri string) (*remoteActor, error) {
+	document, err := fetchActor(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.saveActor(ctx, document)
+}
+
+// fetch an actor document and make sure it describes itself, uri may also be the URI
+// of a key that lives at its own address, in which case its owner is fetched
+func fetchActor(ctx context.Context, uri string) (*activitypub.Actor, error) {
+	var document activitypub.Actor
+	if err := activitypub.Fetch(ctx, uri, &document); err != nil {
+		return nil, err
+	}
+	if document.Inbox == "" && document.PublicKey.Owner != "" && document.PublicKey.Owner != document.ID {
+		owner := document.PublicKey.Owner
+		if !sameHost(owner, uri) {
+			return nil, errors.New(httpcommon.ErrorMessage.InvalidActivity)
+		}
+		document = activitypub.Actor{}
+		if err := activitypub.Fetch(ctx, owner, &document); err != nil {
+			return nil, err
+		}
+		uri = owner
+	}
+
+	// an actor is only trusted to describe itself
+	if document.ID != uri || document.PublicKey.Owner != document.ID || document.PublicKey.PublicKeyPem == "" ||
+		!sameHost(document.PublicKey.ID, document.ID) || activitypub.CheckURL(document.Inbox) != nil {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidActivity)
+	}
+
+	return &document, nil
+}
+
+// insert or update the cached copy of an actor document
+func (f *FederationService) saveActor(ctx context.Context, document *activitypub.Actor) (*remoteActor, error) {
+	var sharedInbox *string
+	if document.Endpoints != nil && activitypub.CheckURL(document.Endpoints.SharedInbox) == nil {
+		sharedInbox = &document.Endpoints.SharedInbox
+	}
+
+	query := `
+		INSERT INTO remote_actors (actor_uri, username, inbox_uri, shared_inbox_uri, key_id, public_key_pem, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			username = VALUES(username),
+			inbox_uri = VALUES(inbox_uri),
+			shared_inbox_uri = VALUES(shared_inbox_uri),
+			key_id = VALUES(key_id),
+			public_key_pem = VALUES(public_key_pem),
+			fetched_at = VALUES(fetched_at)
+	`
+	_, err := f.db.ExecContext(
+		ctx,
+		query,
+		document.ID,
+		document.PreferredUsername,
+		document.Inbox,
+		sharedInbox,
+		document.PublicKey.ID,
+		document.PublicKey.PublicKeyPem,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return findRemoteActor(ctx, f.db, "actor_uri", document.ID)
+}
+
+// helper function to look up a cached remote actor by actor_uri or key_id
+func findRemoteActor(ctx context.Context, db dbExecutor, column string, value string) (*remoteActor, error) {
+	query := fmt.Sprintf(`
+		SELECT id, actor_uri, username, inbox_uri, key_id, public_key_pem
+		FROM remote_actors
+		WHERE %s = ?
+	`, column)
+
+	var actor remoteActor
+	if err := db.QueryRowContext(ctx, query, value).Scan(
+		&actor.id,
+		&actor.uri,
+		&actor.username,
+		&actor.inbox,
+		&actor.keyId,
+		&actor.publicKeyPem,
+	); err != nil {
+		return nil, err
+	}
+
+	return &actor, nil
+}
+
+// read a user's key pair, generating it the first time the user is federated
+func ensureActorKey(ctx context.Context, db dbExecutor, userId uint64) (string, string, error) {
+	query := "SELECT public_key_pem, private_key_pem FROM actor_keys WHERE user_id = ?"
+
+	var publicKeyPem, privateKeyPem string
+	err := db.QueryRowContext(ctx, query, userId).Scan(&publicKeyPem, &privateKeyPem)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return publicKeyPem, privateKeyPem, err
+	}
+
+	publicKeyPem, privateKeyPem, err = activitypub.GenerateKeyPair()
+	if err != nil {
+		return "", "", err
+	}
+	// whoever stores a key first wins, so read it back in case a concurrent request did
+	_, err = db.ExecContext(
+		ctx,
+		"INSERT IGNORE INTO actor_keys (user_id, public_key_pem, private_key_pem) VALUES (?, ?, ?)",
+		userId,
+		publicKeyPem,
+		privateKeyPem,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = db.QueryRowContext(ctx, query, userId).Scan(&publicKeyPem, &privateKeyPem)
+	return publicKeyPem, privateKeyPem, err
+}
+
+func loadSigningKey(ctx context.Context, db dbExecutor, userId uint64) (*signingKey, error) {
+	var username string
+	if err := db.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", userId).Scan(&username); err != nil {
+		return nil, err
+	}
+
+	_, privateKeyPem, err := ensureActorKey(ctx, db, userId)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := activitypub.ParsePrivateKey(privateKeyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signingKey{id: actorURI(username) + "#main-key", privateKey: privateKey}, nil
+}
+
+// build the note of a post along with its author, nil if the post isn't federated:
+// reposts, private posts, hidden posts and posts that aren't published stay on this instance
+func loadFederatedNote(ctx context.Context, db dbExecutor, postId uint64) (*activitypub.Note, uint64, error) {
+	query := `
+		SELECT posts.user_id, users.username, posts.content_html, posts.status, posts.visibility,
+			posts.repost_of_id, posts.hidden_at, posts.publish_at, posts.created_at, posts.updated_at
+		FROM posts JOIN users ON posts.user_id = users.id
+		WHERE posts.id = ?
+	`
+	var authorId uint64
+	var username, contentHtml, status, visibility string
+	var repostOfId *uint64
+	var hiddenAt, publishAt *time.Time
+	var createdAt, updatedAt time.Time
+	if err := db.QueryRowContext(ctx, query, postId).Scan(
+		&authorId,
+		&username,
+		&contentHtml,
+		&status,
+		&visibility,
+		&repostOfId,
+		&hiddenAt,
+		&publishAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, 0, err
+	}
+	if !isFederated(status, visibility, repostOfId, hiddenAt) {
+		return nil, authorId, nil
+	}
+
+	published := createdAt
+	if publishAt != nil {
+		published = *publishAt
+	}
+	note := &activitypub.Note{
+		ID:           noteURI(postId),
+		Type:         "Note",
+		AttributedTo: actorURI(username),
+		Content:      contentHtml,
+		URL:          fmt.Sprintf("%s/posts/%d", httpcommon.FeedConstants.SiteURL, postId),
+		Published:    &published,
+	}
+	if updatedAt.After(published) {
+		note.Updated = &updatedAt
+	}
+
+	followers := actorURI(username) + "/followers"
+	if visibility == httpcommon.PostVisibility.Public {
+		note.To = []string{activitypub.PublicCollection}
+		note.Cc = []string{followers}
+	} else {
+		note.To = []string{followers}
+	}
+
+	return note, authorId, nil
+}
+
+// let the author's remote followers know that a post was created, edited or removed,
+// wasFederated telling if they could see the post before the change
+func federatePostChange(ctx context.Context, db dbExecutor, postId uint64, wasFederated bool) error {
+	note, authorId, err := loadFederatedNote(ctx, db, postId)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case note != nil && !wasFederated:
+		return queueForRemoteFollowers(ctx, db, authorId, createActivity(note))
+	case note != nil:
+		update := activitypub.Activity{
+			Context: activitypub.ObjectContext,
+			ID:      newActivityURI(),
+			Type:    "Update",
+			Actor:   note.AttributedTo,
+			Object:  note,
+			To:      note.To,
+			Cc:      note.Cc,
+		}
+		return queueForRemoteFollowers(ctx, db, authorId, update)
+	case wasFederated:
+		return federatePostDeletion(ctx, db, postId, authorId)
+	}
+
+	return nil
+}
+
+// let the author's remote followers know that a post they could see is gone
+func federatePostDeletion(ctx context.Context, db dbExecutor, postId uint64, authorId uint64) error {
+	var username string
+	if err := db.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", authorId).Scan(&username); err != nil {
+		return err
+	}
+
+	remove := activitypub.Activity{
+		Context: activitypub.ObjectContext,
+		ID:      newActivityURI(),
+		Type:    "Delete",
+		Actor:   actorURI(username),
+		Object:  map[string]string{"id": noteURI(postId), "type": "Tombstone"},
+		To:      []string{activitypub.PublicCollection},
+	}
+
+	return queueForRemoteFollowers(ctx, db, authorId, remove)
+}
+
+func isFederated(status string, visibility string, repostOfId *uint64, hiddenAt *time.Time) bool {
+	return status == httpcommon.PostStatus.Published &&
+		visibility != httpcommon.PostVisibility.Private &&
+		repostOfId == nil &&
+		hiddenAt == nil
+}
+
+func createActivity(note *activitypub.Note) activitypub.Activity {
+	return activitypub.Activity{
+		Context: activitypub.ObjectContext,
+		ID:      note.ID + "/activity",
+		Type:    "Create",
+		Actor:   note.AttributedTo,
+		Object:  note,
+		To:      note.To,
+		Cc:      note.Cc,
+	}
+}
+
+func actorURI(username string) string {
+	return httpcommon.FederationConstants.BaseURL + "/ap/users/" + url.PathEscape(username)
+}
+
+func noteURI(postId uint64) string {
+	return fmt.Sprintf("%s/ap/posts/%d", httpcommon.FederationConstants.BaseURL, postId)
+}
+
+// activities other than Create aren't served, so they only need to be unique
+func newActivityURI() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return httpcommon.FederationConstants.BaseURL + "/ap/activities/" + hex.EncodeToString(id)
+}
+
+func usernameFromActorURI(uri string) (string, bool) {
+	escaped, ok := strings.CutPrefix(uri, httpcommon.FederationConstants.BaseURL+"/ap/users/")
+	if !ok || strings.Contains(escaped, "/") {
+		return "", false
+	}
+	username, err := url.PathUnescape(escaped)
+	if err != nil || username == "" {
+		return "", false
+	}
+	return username, true
+}
+
+// helper function to build the user@domain handle of a remote actor
+func remoteHandle(username string, uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return username
+	}
+	return username + "@" + parsed.Host
+}
+
+func sameHost(a string, b string) bool {
+	parsedA, errA := url.Parse(a)
+	parsedB, errB := url.Parse(b)
+	return errA == nil && errB == nil && parsedA.Host != "" && strings.EqualFold(parsedA.Host, parsedB.Host)
+}
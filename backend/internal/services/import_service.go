@@ -0,0 +1,267 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/filters"
+	"chi-mysql-boilerplate/internal/utils/export"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/markdown"
+	"chi-mysql-boilerplate/internal/utils/validators"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+type ImportService struct {
+	db        *sql.DB
+	validator *validators.Validator
+}
+
+func NewImportService(db *sql.DB, validator *validators.Validator) *ImportService {
+	return &ImportService{db: db, validator: validator}
+}
+
+// create posts for a user from the rows of an import file, keeping their original timestamps.
+// rows whose import key the user already has are skipped, so an import can safely be re-run.
+// in atomic mode nothing is kept unless every row goes through, in best-effort mode
+// every row is imported on its own. mentioned users aren't notified about old content
+func (is *ImportService) Import(ctx context.Context, userId uint64, rows []export.DecodedRow, mode string) (*models.ImportReport, error) {
+	if mode != httpcommon.ImportMode.Atomic && mode != httpcommon.ImportMode.BestEffort {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidImportMode)
+	}
+
+	report := &models.ImportReport{Mode: mode, Rows: make([]models.ImportRowResult, len(rows))}
+
+	// validate everything up front so an atomic import doesn't touch the database for nothing
+	for i, row := range rows {
+		result := &report.Rows[i]
+		result.Row = i + 1
+		result.ImportKey = importKey(row.Post)
+
+		if row.Err != nil {
+			result.Status = httpcommon.ImportRowStatus.Rejected
+			result.Errors = []httpcommon.Error{{Message: row.Err.Error(), Code: httpcommon.ErrorResponseCode.InvalidDataType}}
+			continue
+		}
+		if errs := is.validator.ValidateStruct(&row.Post); errs != nil {
+			result.Status = httpcommon.ImportRowStatus.Rejected
+			result.Errors = errs
+		}
+	}
+
+	if mode == httpcommon.ImportMode.Atomic {
+		if err := is.importAtomically(ctx, userId, rows, report); err != nil {
+			return nil, err
+		}
+	} else {
+		for i, row := range rows {
+			if report.Rows[i].Status != "" {
+				continue
+			}
+			if err := is.importInTransaction(ctx, userId, row.Post, &report.Rows[i]); err != nil {
+				return nil, err
+			}
+		}
+		report.Committed = true
+	}
+
+	for _, result := range report.Rows {
+		switch result.Status {
+		case httpcommon.ImportRowStatus.Imported:
+			report.Imported++
+		case httpcommon.ImportRowStatus.Duplicate:
+			report.Duplicates++
+		case httpcommon.ImportRowStatus.Rejected:
+			report.Rejected++
+		}
+	}
+
+	return report, nil
+}
+
+func (is *ImportService) importAtomically(ctx context.Context, userId uint64, rows []export.DecodedRow, report *models.ImportReport) error {
+	failed := false
+	for _, result := range report.Rows {
+		if result.Status == httpcommon.ImportRowStatus.Rejected {
+			failed = true
+		}
+	}
+
+	if !failed {
+		tx, err := is.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for i, row := range rows {
+			if err = importRow(ctx, tx, userId, row.Post, &report.Rows[i]); err != nil {
+				return err
+			}
+			if report.Rows[i].Status == httpcommon.ImportRowStatus.Rejected {
+				failed = true
+				break
+			}
+		}
+
+		if !failed {
+			if err = tx.Commit(); err != nil {
+				return err
+			}
+			report.Committed = true
+			return nil
+		}
+	}
+
+	// everything was rolled back, so the rows that were fine weren't imported either
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		if result.Status != httpcommon.ImportRowStatus.Rejected {
+			result.Status = httpcommon.ImportRowStatus.Rejected
+			result.PostID = nil
+			result.Errors = []httpcommon.Error{{Message: httpcommon.ErrorMessage.ImportAborted, Code: httpcommon.ErrorResponseCode.InvalidRequest}}
+		}
+	}
+
+	return nil
+}
+
+func (is *ImportService) importInTransaction(ctx context.Context, userId uint64, post models.ImportedPost, result *models.ImportRowResult) error {
+	tx, err := is.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = importRow(ctx, tx, userId, post, result); err != nil {
+		return err
+	}
+	if result.Status != httpcommon.ImportRowStatus.Imported {
+		return nil
+	}
+	if err = tx.Commit(); err != nil {
+		helpers.MessageLogs.ErrorLog.Printf("import row %d: %v", result.Row, err)
+		return err
+	}
+
+	return nil
+}
+
+// import a single valid row inside a transaction, recording the outcome in result.
+// only a row the request got wrong is rejected, any other failure is returned and fails the whole import
+func importRow(ctx context.Context, tx *sql.Tx, userId uint64, post models.ImportedPost, result *models.ImportRowResult) error {
+	postId, duplicate, err := insertImportedPost(ctx, tx, userId, post, result.ImportKey)
+	switch {
+	case err != nil && httpcommon.IsBadRequestMessage(err.Error()):
+		result.Status = httpcommon.ImportRowStatus.Rejected
+		result.Errors = []httpcommon.Error{{Message: err.Error(), Code: httpcommon.ErrorResponseCode.InvalidRequest}}
+	case err != nil:
+		helpers.MessageLogs.ErrorLog.Printf("import row %d: %v", result.Row, err)
+		return err
+	case duplicate:
+		result.Status = httpcommon.ImportRowStatus.Duplicate
+		result.PostID = &postId
+	default:
+		result.Status = httpcommon.ImportRowStatus.Imported
+		result.PostID = &postId
+	}
+
+	return nil
+}
+
+// returns the ID of the existing post instead when the user already imported this key
+func insertImportedPost(ctx context.Context, tx *sql.Tx, userId uint64, post models.ImportedPost, key string) (uint64, bool, error) {
+	var existingId uint64
+	err := tx.QueryRowContext(ctx, "SELECT id FROM posts WHERE user_id = ? AND import_key = ?", userId, key).Scan(&existingId)
+	if err == nil {
+		return existingId, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	now := time.Now()
+	createdAt := now
+	if post.CreatedAt != nil {
+		createdAt = *post.CreatedAt
+	}
+	updatedAt := createdAt
+	if post.UpdatedAt != nil {
+		updatedAt = *post.UpdatedAt
+	}
+	visibility := post.Visibility
+	if visibility == "" {
+		visibility = httpcommon.PostVisibility.Public
+	}
+
+	// posts published on the old platform went out when they were written unless told otherwise
+	status, publishAt := post.Status, post.PublishAt
+	if status == "" || status == httpcommon.PostStatus.Published {
+		status = httpcommon.PostStatus.Published
+		if publishAt == nil {
+			publishAt = &createdAt
+		}
+	} else {
+		status, publishAt, err = resolvePublishing(models.PostRequest{Status: status, PublishAt: publishAt}, "", nil, now)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	// imports go through the content filters like any other new post, drafts once they're about to go out
+	var verdict *filters.Verdict
+	if status != httpcommon.PostStatus.Draft {
+		if verdict, err = filterPost(ctx, tx, 0, userId, post.Content); err != nil {
+			return 0, false, err
+		}
+		if verdict != nil && verdict.Action == filters.ActionHold {
+			status = httpcommon.PostStatus.Held
+		}
+	}
+
+	query := `
+		INSERT INTO posts (content, content_html, user_id, status, publish_at, visibility, import_key, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := tx.ExecContext(ctx, query, post.Content, markdown.Render(post.Content), userId, status, publishAt, visibility, key, createdAt, updatedAt)
+	if err != nil {
+		return 0, false, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, false, err
+	}
+
+	if err = syncPostTags(ctx, tx, uint64(id), post.Content); err != nil {
+		return 0, false, err
+	}
+	if err = syncPostMentions(ctx, tx, uint64(id), post.Content); err != nil {
+		return 0, false, err
+	}
+	if err = syncPostLinks(ctx, tx, uint64(id), post.Content); err != nil {
+		return 0, false, err
+	}
+	if err = reportFilteredPost(ctx, tx, uint64(id), userId, verdict); err != nil {
+		return 0, false, err
+	}
+
+	return uint64(id), false, nil
+}
+
+// rows without an import key are identified by when they were written and what they say
+func importKey(post models.ImportedPost) string {
+	if post.ImportKey != "" {
+		return post.ImportKey
+	}
+
+	createdAt := ""
+	if post.CreatedAt != nil {
+		createdAt = post.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(createdAt + "\n" + post.Content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
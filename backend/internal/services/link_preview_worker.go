@@ -0,0 +1,321 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/utils/entities"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/linkpreview"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how many pages of a batch are fetched at once
+const linkPreviewConcurrency = 4
+
+// fetches the previews posts are waiting on, outside of the requests that created them
+type LinkPreviewWorker struct {
+	db *sql.DB
+}
+
+func NewLinkPreviewWorker(db *sql.DB) *LinkPreviewWorker {
+	return &LinkPreviewWorker{db: db}
+}
+
+type linkPreviewJob struct {
+	id       uint64
+	url      string
+	attempts int
+}
+
+// fetch due previews on every tick until the context is cancelled
+func (lw *LinkPreviewWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(httpcommon.LinkPreviewConstants.FetchInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			claimed, err := lw.FetchDue(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					helpers.MessageLogs.ErrorLog.Println(err)
+				}
+				break
+			}
+			if claimed < httpcommon.LinkPreviewConstants.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim a batch of due previews and fetch them, returns how many were claimed.
+// like deliveries, claiming only pushes the next attempt back by the lease
+func (lw *LinkPreviewWorker) FetchDue(ctx context.Context) (int, error) {
+	jobs, err := lw.claimJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, linkPreviewConcurrency)
+	for _, job := range jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(job linkPreviewJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			preview, err := linkpreview.Fetch(ctx, job.url)
+			if ctx.Err() != nil {
+				// shutting down, the lease runs out and the preview is picked up again
+				return
+			}
+			if err = lw.recordFetch(job, preview, err); err != nil {
+				helpers.MessageLogs.ErrorLog.Println(err)
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+// pick the due jobs and push their next attempt back so no other worker picks them meanwhile,
+// jobs another worker is claiming at the same moment are skipped rather than waited on
+func (lw *LinkPreviewWorker) claimJobs(ctx context.Context) ([]linkPreviewJob, error) {
+	tx, err := lw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, url, attempts
+		FROM link_previews
+		WHERE next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, query, now, httpcommon.LinkPreviewConstants.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []linkPreviewJob
+	var ids []uint64
+	for rows.Next() {
+		var job linkPreviewJob
+		if err := rows.Scan(&job.id, &job.url, &job.attempts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, job.id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	query = fmt.Sprintf("UPDATE link_previews SET next_attempt_at = ? WHERE id IN (%s)", placeholders(len(ids)))
+	args := append([]interface{}{now.Add(httpcommon.LinkPreviewConstants.FetchLease)}, idsToArgs(ids)...)
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// a failed fetch is retried with exponential backoff while it may still work out.
+// once it's given up on, a preview fetched before is kept until it goes stale again
+func (lw *LinkPreviewWorker) recordFetch(job linkPreviewJob, preview *linkpreview.Preview, fetchErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	now := time.Now()
+	if fetchErr == nil {
+		query := `
+			UPDATE link_previews
+			SET status = ?, title = ?, description = ?, image_url = ?, site_name = ?,
+				attempts = 0, next_attempt_at = NULL, fetched_at = ?
+			WHERE id = ?
+		`
+		_, err := lw.db.ExecContext(ctx, query,
+			httpcommon.LinkPreviewStatus.Ready,
+			nullIfEmpty(preview.Title),
+			nullIfEmpty(preview.Description),
+			nullIfEmpty(preview.ImageURL),
+			nullIfEmpty(preview.SiteName),
+			now,
+			job.id,
+		)
+		return err
+	}
+
+	attempts := job.attempts + 1
+	if linkpreview.Retryable(fetchErr) && attempts < httpcommon.LinkPreviewConstants.MaxAttempts {
+		nextAttemptAt := now.Add(httpcommon.LinkPreviewConstants.RetryDelay << (attempts - 1))
+		_, err := lw.db.ExecContext(ctx, "UPDATE link_previews SET attempts = ?, next_attempt_at = ? WHERE id = ?", attempts, nextAttemptAt, job.id)
+		return err
+	}
+
+	query := `
+		UPDATE link_previews
+		SET status = IF(status = ?, status, ?), attempts = ?, next_attempt_at = NULL, fetched_at = ?
+		WHERE id = ?
+	`
+	_, err := lw.db.ExecContext(ctx, query, httpcommon.LinkPreviewStatus.Ready, httpcommon.LinkPreviewStatus.Failed, attempts, now, job.id)
+
+	return err
+}
+
+// helper function to pick the links of a post that get a preview: distinct, fetchable
+// and no more than the limit, in order of appearance
+func previewLinks(content string) []string {
+	links := []string{}
+	seen := map[string]bool{}
+	for _, link := range entities.Links(content) {
+		if len(links) == httpcommon.LinkPreviewConstants.MaxPerPost {
+			break
+		}
+		if seen[link] || len(link) > 2048 || linkpreview.CheckURL(link) != nil {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+// point a post at the previews of the links in its content, queueing a fetch
+// for links nobody posted before and for previews that went stale
+func syncPostLinks(ctx context.Context, tx *sql.Tx, postId uint64, content string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_links WHERE post_id = ?", postId); err != nil {
+		return err
+	}
+
+	links := previewLinks(content)
+	if len(links) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	hashArgs := make([]interface{}, len(links))
+	insertArgs := make([]interface{}, 0, len(links)*3)
+	for i, link := range links {
+		hash := sha256.Sum256([]byte(link))
+		hashArgs[i] = hash[:]
+		insertArgs = append(insertArgs, link, hash[:], now)
+	}
+
+	query := fmt.Sprintf(
+		"INSERT IGNORE INTO link_previews (url, url_hash, next_attempt_at) VALUES %s",
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(links)), ", "),
+	)
+	if _, err := tx.ExecContext(ctx, query, insertArgs...); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`
+		UPDATE link_previews
+		SET attempts = 0, next_attempt_at = ?
+		WHERE url_hash IN (%s) AND next_attempt_at IS NULL AND fetched_at < ?
+	`, placeholders(len(links)))
+	args := append(append([]interface{}{now}, hashArgs...), now.Add(-httpcommon.LinkPreviewConstants.CacheTTL))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf("SELECT id, url FROM link_previews WHERE url_hash IN (%s)", placeholders(len(links)))
+	rows, err := tx.QueryContext(ctx, query, hashArgs...)
+	if err != nil {
+		return err
+	}
+	previewIds := map[string]uint64{}
+	for rows.Next() {
+		var id uint64
+		var url string
+		if err := rows.Scan(&id, &url); err != nil {
+			rows.Close()
+			return err
+		}
+		previewIds[url] = id
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	args = make([]interface{}, 0, len(links)*3)
+	for i, link := range links {
+		args = append(args, postId, previewIds[link], i)
+	}
+	query = fmt.Sprintf(
+		"INSERT INTO post_links (post_id, link_preview_id, position) VALUES %s",
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(links)), ", "),
+	)
+	_, err = tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// load the fetched previews of a batch of posts, in the order their links appear
+func loadLinkPreviews(ctx context.Context, db *sql.DB, postIds []uint64) (map[uint64][]models.LinkPreviewResponse, error) {
+	previews := make(map[uint64][]models.LinkPreviewResponse, len(postIds))
+	if len(postIds) == 0 {
+		return previews, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT post_id, url, title, description, image_url, site_name
+		FROM post_links JOIN link_previews ON post_links.link_preview_id = link_previews.id
+		WHERE post_id IN (%s) AND status = ?
+		ORDER BY post_id, position
+	`, placeholders(len(postIds)))
+	args := append(idsToArgs(postIds), httpcommon.LinkPreviewStatus.Ready)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId uint64
+		var preview models.LinkPreviewResponse
+		if err := rows.Scan(
+			&postId,
+			&preview.URL,
+			&preview.Title,
+			&preview.Description,
+			&preview.ImageURL,
+			&preview.SiteName,
+		); err != nil {
+			return nil, err
+		}
+
+		previews[postId] = append(previews[postId], preview)
+	}
+
+	return previews, rows.Err()
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/storage"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"chi-mysql-boilerplate/internal/utils/images"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type MediaService struct {
+	db      *sql.DB
+	storage storage.Storage
+}
+
+func NewMediaService(db *sql.DB, storage storage.Storage) *MediaService {
+	return &MediaService{db: db, storage: storage}
+}
+
+// process an uploaded image (metadata stripped, thumbnail generated) and store it
+func (m *MediaService) Upload(userId uint64, data []byte) (*models.MediaResponse, error) {
+	processed, err := images.Process(data, httpcommon.MediaConstants.MaxPixels, httpcommon.MediaConstants.ThumbnailSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, images.ErrUnsupportedType):
+			return nil, errors.New(httpcommon.ErrorMessage.UnsupportedMedia)
+		case errors.Is(err, images.ErrTooLarge):
+			return nil, errors.New(httpcommon.ErrorMessage.MediaTooLarge)
+		case errors.Is(err, images.ErrCorruptImage):
+			return nil, errors.New(httpcommon.ErrorMessage.CorruptMedia)
+		default:
+			return nil, err
+		}
+	}
+
+	// uploads can take a while, so they get a longer timeout than the usual queries
+	ctx, cancel := context.WithTimeout(context.Background(), 6*httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("media/%d/%s%s", userId, name, processed.Image.Extension)
+	thumbnailKey := fmt.Sprintf("media/%d/%s_thumb%s", userId, name, processed.Thumbnail.Extension)
+
+	if err = m.storage.Put(ctx, key, processed.Image.Data, processed.Image.ContentType); err != nil {
+		return nil, err
+	}
+	if err = m.storage.Put(ctx, thumbnailKey, processed.Thumbnail.Data, processed.Thumbnail.ContentType); err != nil {
+		m.deleteStored(ctx, key)
+		return nil, err
+	}
+
+	media := models.MediaResponse{
+		URL:          m.storage.URL(key),
+		ThumbnailURL: m.storage.URL(thumbnailKey),
+		MimeType:     processed.Image.ContentType,
+		Width:        processed.Image.Width,
+		Height:       processed.Image.Height,
+		SizeBytes:    len(processed.Image.Data),
+		CreatedAt:    time.Now(),
+	}
+
+	query := `
+		INSERT INTO media (user_id, storage_key, thumbnail_key, url, thumbnail_url, mime_type, width, height, size_bytes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := m.db.ExecContext(ctx, query,
+		userId, key, thumbnailKey, media.URL, media.ThumbnailURL,
+		media.MimeType, media.Width, media.Height, media.SizeBytes, media.CreatedAt,
+	)
+	if err != nil {
+		// don't leave files behind that nothing points to
+		m.deleteStored(ctx, key, thumbnailKey)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	media.ID = uint64(id)
+
+	return &media, nil
+}
+
+// clean up objects an upload that failed halfway left behind. failures are only logged since the
+// upload has already failed, the objects are orphaned from then on and have to be removed by hand
+func (m *MediaService) deleteStored(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := m.storage.Delete(ctx, key); err != nil {
+			helpers.MessageLogs.ErrorLog.Printf("failed to delete orphaned object %s: %v", key, err)
+		}
+	}
+}
+
+func randomName() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// attach the given media items to a post in order, replacing whatever was attached before
+// a nil list leaves the attachments untouched, has to run inside the transaction that writes the post
+func syncPostMedia(ctx context.Context, tx *sql.Tx, postId uint64, authorId uint64, media []models.PostMediaRequest) error {
+	if media == nil {
+		return nil
+	}
+	if len(media) > httpcommon.MediaConstants.MaxPerPost {
+		return errors.New(httpcommon.ErrorMessage.TooManyMedia)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_media WHERE post_id = ?", postId); err != nil {
+		return err
+	}
+	if len(media) == 0 {
+		return nil
+	}
+
+	// every item has to be one of the author's own uploads, and can only be attached once
+	mediaIds := make([]uint64, len(media))
+	seen := map[uint64]bool{}
+	for i, item := range media {
+		if seen[item.ID] {
+			return errors.New(httpcommon.ErrorMessage.InvalidMedia)
+		}
+		seen[item.ID] = true
+		mediaIds[i] = item.ID
+	}
+	query := fmt.Sprintf("SELECT id FROM media WHERE user_id = ? AND id IN (%s)", placeholders(len(mediaIds)))
+	ownedIds, err := queryIds(ctx, tx, query, append([]interface{}{authorId}, idsToArgs(mediaIds)...)...)
+	if err != nil {
+		return err
+	}
+	if len(ownedIds) != len(mediaIds) {
+		return errors.New(httpcommon.ErrorMessage.InvalidMedia)
+	}
+
+	args := make([]interface{}, 0, 4*len(media))
+	for position, item := range media {
+		args = append(args, postId, item.ID, position, item.AltText)
+	}
+	query = `
+		INSERT INTO post_media (post_id, media_id, position, alt_text)
+		VALUES ` + strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?), ", len(media)), ", ")
+	_, err = tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// fetch the media attached to a batch of posts, in order
+func loadPostMedia(ctx context.Context, db *sql.DB, postIds []uint64) (map[uint64][]models.PostMediaResponse, error) {
+	media := make(map[uint64][]models.PostMediaResponse, len(postIds))
+	if len(postIds) == 0 {
+		return media, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT post_id, media.id, url, thumbnail_url, mime_type, width, height, alt_text
+		FROM post_media JOIN media ON post_media.media_id = media.id
+		WHERE post_id IN (%s)
+		ORDER BY post_id, position
+	`, placeholders(len(postIds)))
+	rows, err := db.QueryContext(ctx, query, idsToArgs(postIds)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId uint64
+		var item models.PostMediaResponse
+		if err := rows.Scan(
+			&postId,
+			&item.ID,
+			&item.URL,
+			&item.ThumbnailURL,
+			&item.MimeType,
+			&item.Width,
+			&item.Height,
+			&item.AltText,
+		); err != nil {
+			return nil, err
+		}
+
+		media[postId] = append(media[postId], item)
+	}
+
+	return media, rows.Err()
+}
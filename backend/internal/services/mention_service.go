@@ -0,0 +1,148 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/utils/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// store the @mentions in a post's content that resolve to existing users,
+// has to run inside the transaction that writes the content
+func syncPostMentions(ctx context.Context, tx *sql.Tx, postId uint64, content string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_mentions WHERE post_id = ?", postId); err != nil {
+		return err
+	}
+
+	mentions := entities.Mentions(content)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	// resolve the usernames, the column's collation makes the lookup case-insensitive
+	usernames := make([]interface{}, len(mentions))
+	for i, mention := range mentions {
+		usernames[i] = mention.Username
+	}
+	query := fmt.Sprintf("SELECT id, username FROM users WHERE username IN (%s)", placeholders(len(usernames)))
+	rows, err := tx.QueryContext(ctx, query, usernames...)
+	if err != nil {
+		return err
+	}
+	userIds := map[string]uint64{}
+	for rows.Next() {
+		var id uint64
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			rows.Close()
+			return err
+		}
+		userIds[strings.ToLower(username)] = id
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	args := []interface{}{}
+	for _, mention := range mentions {
+		if userId, ok := userIds[strings.ToLower(mention.Username)]; ok {
+			args = append(args, postId, userId, mention.Start, mention.End)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	query = `
+		INSERT INTO post_mentions (post_id, user_id, start_offset, end_offset)
+		VALUES ` + strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?), ", len(args)/4), ", ")
+	_, err = tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// notify the users mentioned in a published post who haven't been notified about it before,
+// leaving out anyone the post's visibility hides it from
+func notifyPostMentions(ctx context.Context, tx *sql.Tx, postId uint64, authorId uint64) error {
+	query := `
+		SELECT DISTINCT post_mentions.user_id
+		FROM post_mentions JOIN posts ON post_mentions.post_id = posts.id
+		WHERE post_mentions.post_id = ? AND (
+			posts.visibility = ?
+			OR (posts.visibility = ? AND EXISTS (
+				SELECT 1 FROM follows WHERE follows.follower_id = post_mentions.user_id AND follows.followee_id = posts.user_id
+			))
+		)
+	`
+	mentionedIds, err := queryIds(ctx, tx, query, postId, httpcommon.PostVisibility.Public, httpcommon.PostVisibility.Followers)
+	if err != nil {
+		return err
+	}
+	if len(mentionedIds) == 0 {
+		return nil
+	}
+
+	// edits that keep a mention around shouldn't notify the same user again
+	query = fmt.Sprintf(`
+		SELECT user_id
+		FROM notifications
+		WHERE kind = ? AND post_id = ? AND user_id IN (%s)
+	`, placeholders(len(mentionedIds)))
+	notifiedIds, err := queryIds(ctx, tx, query, append([]interface{}{httpcommon.NotificationKind.Mention, postId}, idsToArgs(mentionedIds)...)...)
+	if err != nil {
+		return err
+	}
+	alreadyNotified := map[uint64]bool{}
+	for _, id := range notifiedIds {
+		alreadyNotified[id] = true
+	}
+
+	recipientIds := []uint64{}
+	for _, id := range mentionedIds {
+		if !alreadyNotified[id] {
+			recipientIds = append(recipientIds, id)
+		}
+	}
+
+	return createNotifications(ctx, tx, models.Notification{
+		Kind:    httpcommon.NotificationKind.Mention,
+		ActorID: authorId,
+		PostID:  &postId,
+	}, recipientIds)
+}
+
+// fetch the resolved mentions of a batch of posts
+func loadMentionEntities(ctx context.Context, db *sql.DB, postIds []uint64) (map[uint64][]models.MentionEntity, error) {
+	mentions := make(map[uint64][]models.MentionEntity, len(postIds))
+	if len(postIds) == 0 {
+		return mentions, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT post_id, user_id, username, start_offset, end_offset
+		FROM post_mentions JOIN users ON post_mentions.user_id = users.id
+		WHERE post_id IN (%s)
+		ORDER BY post_id, start_offset
+	`, placeholders(len(postIds)))
+	rows, err := db.QueryContext(ctx, query, idsToArgs(postIds)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId uint64
+		var mention models.MentionEntity
+		if err := rows.Scan(&postId, &mention.UserID, &mention.UserName, &mention.Start, &mention.End); err != nil {
+			return nil, err
+		}
+
+		mentions[postId] = append(mentions[postId], mention)
+	}
+
+	return mentions, rows.Err()
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type NotificationService struct {
+	db *sql.DB
+}
+
+func NewNotificationService(db *sql.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// kinds whose notifications about the same post are listed as one group
+var groupedKinds = []interface{}{httpcommon.NotificationKind.Reaction, httpcommon.NotificationKind.Follow}
+
+// fetch a page of the user's notifications, newest first. reactions and follows are grouped,
+// the cursor being the ID of the last group's latest notification.
+// with unreadOnly, groups are made of the unread notifications alone
+func (ns *NotificationService) GetByUserId(userId uint64, unreadOnly bool, page models.PageRequest) (*models.Page[*models.NotificationResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	condition := ""
+	if unreadOnly {
+		condition = "AND read_at IS NULL"
+	}
+	// grouped kinds share a key per post, every other notification gets a key of its own
+	query := fmt.Sprintf(`
+		SELECT
+			MAX(id) AS latest_id, kind, MAX(post_id), MAX(comment_id), COUNT(DISTINCT actor_id),
+			MAX(read_at IS NULL), MAX(created_at), GROUP_CONCAT(actor_id ORDER BY id DESC)
+		FROM notifications
+		WHERE user_id = ? %s
+		GROUP BY kind, IF(kind IN (?, ?), COALESCE(post_id, 0), 0), IF(kind IN (?, ?), 0, id)
+		HAVING ? = 0 OR latest_id < ?
+		ORDER BY latest_id DESC
+		LIMIT ?
+	`, condition)
+	args := append([]interface{}{userId}, groupedKinds...)
+	args = append(args, groupedKinds...)
+	// grab one extra row to know if there's a next page
+	args = append(args, page.Cursor, page.Cursor, page.Limit+1)
+
+	rows, err := ns.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.NotificationResponse{}
+	groupActorIds := map[*models.NotificationResponse][]uint64{}
+	actorIds := []uint64{}
+	for rows.Next() {
+		var notification models.NotificationResponse
+		var actors string
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.Kind,
+			&notification.PostID,
+			&notification.CommentID,
+			&notification.ActorCount,
+			&notification.Unread,
+			&notification.CreatedAt,
+			&actors,
+		); err != nil {
+			return nil, err
+		}
+		notification.Actors = []models.NotificationActor{}
+		notifications = append(notifications, &notification)
+
+		if notification.Kind == httpcommon.NotificationKind.Moderation {
+			notification.ActorCount = 0
+			continue
+		}
+		ids := recentActorIds(actors)
+		groupActorIds[&notification] = ids
+		actorIds = append(actorIds, ids...)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	result := &models.Page[*models.NotificationResponse]{Items: notifications}
+	if len(notifications) > page.Limit {
+		result.Items = notifications[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+
+	usernames, err := loadUsernames(ctx, ns.db, actorIds)
+	if err != nil {
+		return nil, err
+	}
+	for notification, ids := range groupActorIds {
+		for _, id := range ids {
+			if username, ok := usernames[id]; ok {
+				notification.Actors = append(notification.Actors, models.NotificationActor{ID: id, UserName: username})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// mark a notification read along with the older ones grouped with it
+func (ns *NotificationService) MarkRead(userId uint64, notificationId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var kind string
+	var postId *uint64
+	query := "SELECT kind, post_id FROM notifications WHERE id = ? AND user_id = ?"
+	if err := ns.db.QueryRowContext(ctx, query, notificationId, userId).Scan(&kind, &postId); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if kind != httpcommon.NotificationKind.Reaction && kind != httpcommon.NotificationKind.Follow {
+		_, err := ns.db.ExecContext(ctx, "UPDATE notifications SET read_at = ? WHERE id = ? AND read_at IS NULL", now, notificationId)
+		return err
+	}
+
+	query = `
+		UPDATE notifications
+		SET read_at = ?
+		WHERE user_id = ? AND kind = ? AND post_id <=> ? AND id <= ? AND read_at IS NULL
+	`
+	_, err := ns.db.ExecContext(ctx, query, now, userId, kind, postId, notificationId)
+
+	return err
+}
+
+func (ns *NotificationService) MarkAllRead(userId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	_, err := ns.db.ExecContext(ctx, "UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL", time.Now(), userId)
+
+	return err
+}
+
+func (ns *NotificationService) GetPreferences(userId uint64) (*models.NotificationPreferencesResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	rows, err := ns.db.QueryContext(ctx, "SELECT kind FROM notification_mutes WHERE user_id = ? ORDER BY kind", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := &models.NotificationPreferencesResponse{Muted: []string{}}
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return nil, err
+		}
+		preferences.Muted = append(preferences.Muted, kind)
+	}
+
+	return preferences, rows.Err()
+}
+
+// replace the kinds the user muted, muting only affects notifications created from now on
+func (ns *NotificationService) SetPreferences(userId uint64, muted []string) (*models.NotificationPreferencesResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := ns.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM notification_mutes WHERE user_id = ?", userId); err != nil {
+		return nil, err
+	}
+	if len(muted) > 0 {
+		args := make([]interface{}, 0, len(muted)*2)
+		for _, kind := range muted {
+			args = append(args, userId, kind)
+		}
+		query := fmt.Sprintf(
+			"INSERT IGNORE INTO notification_mutes (user_id, kind) VALUES %s",
+			strings.TrimSuffix(strings.Repeat("(?, ?), ", len(muted)), ", "),
+		)
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ns.GetPreferences(userId)
+}
+
+// helper function to pick the most recent distinct actors out of a GROUP_CONCAT list
+func recentActorIds(actors string) []uint64 {
+	ids := []uint64{}
+	seen := map[uint64]bool{}
+	for _, field := range strings.Split(actors, ",") {
+		// GROUP_CONCAT cuts long lists short, which may leave a partial ID at the end
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) == httpcommon.NotificationConstants.MaxGroupActors {
+			break
+		}
+	}
+	return ids
+}
+
+func loadUsernames(ctx context.Context, db *sql.DB, userIds []uint64) (map[uint64]string, error) {
+	usernames := make(map[uint64]string, len(userIds))
+	if len(userIds) == 0 {
+		return usernames, nil
+	}
+
+	query := fmt.Sprintf("SELECT id, username FROM users WHERE id IN (%s)", placeholders(len(userIds)))
+	rows, err := db.QueryContext(ctx, query, idsToArgs(userIds)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		usernames[id] = username
+	}
+
+	return usernames, rows.Err()
+}
+
+// notify each recipient about something the actor did, nobody gets notified about their own actions
+// or about kinds they muted
+func createNotifications(ctx context.Context, db dbExecutor, notification models.Notification, recipientIds []uint64) error {
+	if len(recipientIds) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("SELECT user_id FROM notification_mutes WHERE kind = ? AND user_id IN (%s)", placeholders(len(recipientIds)))
+	mutedIds, err := queryIds(ctx, db, query, append([]interface{}{notification.Kind}, idsToArgs(recipientIds)...)...)
+	if err != nil {
+		return err
+	}
+	muted := make(map[uint64]bool, len(mutedIds))
+	for _, id := range mutedIds {
+		muted[id] = true
+	}
+
+	args := []interface{}{}
+	for _, recipientId := range recipientIds {
+		if recipientId == notification.ActorID || muted[recipientId] {
+			continue
+		}
+		muted[recipientId] = true
+		args = append(args, recipientId, notification.Kind, notification.ActorID, notification.PostID, notification.CommentID, time.Now())
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	rowCount := len(args) / 6
+	query = `
+		INSERT INTO notifications (user_id, kind, actor_id, post_id, comment_id, created_at)
+		VALUES ` + strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, ?), ", rowCount), ", ")
+	_, err = db.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// notify a user once about an actor doing something, so reacting with several emoji
+// or following and unfollowing over and over doesn't pile up notifications
+func notifyOnce(ctx context.Context, db dbExecutor, notification models.Notification, recipientId uint64) error {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM notifications
+			WHERE user_id = ? AND kind = ? AND post_id <=> ? AND actor_id = ?
+		)
+	`
+	if err := db.QueryRowContext(ctx, query, recipientId, notification.Kind, notification.PostID, notification.ActorID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	return createNotifications(ctx, db, notification, []uint64{recipientId})
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PinService struct {
+	db          *sql.DB
+	postService *PostService
+}
+
+func NewPinService(db *sql.DB) *PinService {
+	return &PinService{db: db, postService: NewPostService(db)}
+}
+
+// pin one of the user's posts after the ones already pinned, pinning a post twice is a no-op
+func (ps *PinService) Pin(userId uint64, postId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	pinnedIds, err := lockPinnedPosts(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT status, pinned_position
+		FROM posts
+		WHERE id = ? AND user_id = ?
+		FOR UPDATE
+	`
+	var status string
+	var position *int
+	if err = tx.QueryRowContext(ctx, query, postId, userId).Scan(&status, &position); err != nil {
+		return err
+	}
+	if position != nil {
+		return nil
+	}
+	if status != httpcommon.PostStatus.Published {
+		return errors.New(httpcommon.ErrorMessage.CannotPinPost)
+	}
+	if len(pinnedIds) >= httpcommon.PinConstants.MaxPinned {
+		return errors.New(httpcommon.ErrorMessage.TooManyPinnedPosts)
+	}
+
+	// pinning isn't an edit, so updated_at is kept as it is
+	query = "UPDATE posts SET pinned_position = ?, updated_at = updated_at WHERE id = ?"
+	if _, err = tx.ExecContext(ctx, query, len(pinnedIds)+1, postId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// unpinning a post that isn't pinned is a no-op
+func (ps *PinService) Unpin(userId uint64, postId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = lockPinnedPosts(ctx, tx, userId); err != nil {
+		return err
+	}
+	if err = unpinPost(ctx, tx, userId, postId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// put the user's pinned posts in a new order, postIds has to list every one of them
+func (ps *PinService) Reorder(userId uint64, postIds []uint64) ([]*models.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pinnedIds, err := lockPinnedPosts(ctx, tx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(postIds) != len(pinnedIds) {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidPinOrder)
+	}
+	pinned := make(map[uint64]bool, len(pinnedIds))
+	for _, id := range pinnedIds {
+		pinned[id] = true
+	}
+	for _, id := range postIds {
+		if !pinned[id] {
+			return nil, errors.New(httpcommon.ErrorMessage.InvalidPinOrder)
+		}
+		// a duplicate would leave another pinned post out
+		delete(pinned, id)
+	}
+
+	if len(postIds) > 0 {
+		// clear the positions first so the unique key doesn't trip over posts swapping places
+		query := fmt.Sprintf("UPDATE posts SET pinned_position = NULL, updated_at = updated_at WHERE id IN (%s)", placeholders(len(postIds)))
+		if _, err = tx.ExecContext(ctx, query, idsToArgs(postIds)...); err != nil {
+			return nil, err
+		}
+		for i, id := range postIds {
+			query = "UPDATE posts SET pinned_position = ?, updated_at = updated_at WHERE id = ?"
+			if _, err = tx.ExecContext(ctx, query, i+1, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ps.GetPinned(userId, userId)
+}
+
+// the user's pinned posts the viewer is allowed to see, in the order they were pinned in
+func (ps *PinService) GetPinned(userId uint64, viewerId uint64) ([]*models.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	visible, args := visibleTo(viewerId)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts JOIN users ON posts.user_id = users.id
+		WHERE posts.user_id = ? AND posts.pinned_position IS NOT NULL AND %s
+		ORDER BY posts.pinned_position
+	`, postResponseColumns, visible)
+
+	return ps.postService.queryPosts(ctx, viewerId, query, append([]interface{}{userId}, args...)...)
+}
+
+// lock a user's pinned posts so concurrent pins can't pick the same position, returns them in order
+func lockPinnedPosts(ctx context.Context, tx *sql.Tx, userId uint64) ([]uint64, error) {
+	query := `
+		SELECT id FROM posts
+		WHERE user_id = ? AND pinned_position IS NOT NULL
+		ORDER BY pinned_position
+		FOR UPDATE
+	`
+	return queryIds(ctx, tx, query, userId)
+}
+
+// unpin a post and move the ones pinned after it up a place
+func unpinPost(ctx context.Context, tx *sql.Tx, userId uint64, postId uint64) error {
+	var position *int
+	query := "SELECT pinned_position FROM posts WHERE id = ? AND user_id = ?"
+	if err := tx.QueryRowContext(ctx, query, postId, userId).Scan(&position); err != nil {
+		return err
+	}
+	if position == nil {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE posts SET pinned_position = NULL, updated_at = updated_at WHERE id = ?", postId); err != nil {
+		return err
+	}
+
+	// in order, since the unique key is checked row by row
+	query = `
+		UPDATE posts
+		SET pinned_position = pinned_position - 1, updated_at = updated_at
+		WHERE user_id = ? AND pinned_position > ?
+		ORDER BY pinned_position
+	`
+	_, err := tx.ExecContext(ctx, query, userId, *position)
+
+	return err
+}
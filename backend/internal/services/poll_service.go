@@ -0,0 +1,264 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PollService struct {
+	db *sql.DB
+}
+
+func NewPollService(db *sql.DB) *PollService {
+	return &PollService{db: db}
+}
+
+// cast the user's vote in the poll attached to a post, votes can't be changed afterwards.
+// returns the poll as the voter now sees it
+func (ps *PollService) Vote(postId uint64, userId uint64, optionIds []uint64) (*models.PollResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT polls.id, polls.multiple_choice, polls.closes_at, posts.status
+		FROM polls JOIN posts ON polls.post_id = posts.id
+		WHERE polls.post_id = ?
+	`
+	var pollId uint64
+	var multipleChoice bool
+	var closesAt time.Time
+	var status string
+	if err = tx.QueryRowContext(ctx, query, postId).Scan(&pollId, &multipleChoice, &closesAt, &status); err != nil {
+		return nil, err
+	}
+	if status != httpcommon.PostStatus.Published || !time.Now().Before(closesAt) {
+		return nil, errors.New(httpcommon.ErrorMessage.PollClosed)
+	}
+
+	// the options have to be distinct ones of this poll
+	seen := make(map[uint64]bool, len(optionIds))
+	for _, id := range optionIds {
+		if seen[id] {
+			return nil, errors.New(httpcommon.ErrorMessage.InvalidPollVote)
+		}
+		seen[id] = true
+	}
+	if !multipleChoice && len(optionIds) != 1 {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidPollVote)
+	}
+	query = fmt.Sprintf("SELECT id FROM poll_options WHERE poll_id = ? AND id IN (%s)", placeholders(len(optionIds)))
+	validIds, err := queryIds(ctx, tx, query, append([]interface{}{pollId}, idsToArgs(optionIds)...)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(validIds) != len(optionIds) {
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidPollVote)
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT IGNORE INTO poll_votes (poll_id, user_id, created_at) VALUES (?, ?, ?)", pollId, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, errors.New(httpcommon.ErrorMessage.AlreadyVoted)
+	}
+
+	args := make([]interface{}, 0, len(optionIds)*3)
+	for _, optionId := range optionIds {
+		args = append(args, pollId, userId, optionId)
+	}
+	query = fmt.Sprintf(
+		"INSERT INTO poll_vote_options (poll_id, user_id, option_id) VALUES %s",
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(optionIds)), ", "),
+	)
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	polls, err := loadPolls(ctx, ps.db, []uint64{postId}, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return polls[postId], nil
+}
+
+// attach a poll to a post that's being created. it has to close after the post goes out,
+// and within the longest time a poll may run from then
+func createPoll(ctx context.Context, tx *sql.Tx, postId uint64, poll models.PollRequest, publishAt *time.Time, now time.Time) error {
+	opensAt := now
+	if publishAt != nil && publishAt.After(now) {
+		opensAt = *publishAt
+	}
+	if !poll.ClosesAt.After(opensAt) || poll.ClosesAt.After(opensAt.Add(httpcommon.PollConstants.MaxDuration)) {
+		return errors.New(httpcommon.ErrorMessage.InvalidPollClosesAt)
+	}
+
+	seen := make(map[string]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		key := strings.ToLower(strings.TrimSpace(option))
+		if seen[key] {
+			return errors.New(httpcommon.ErrorMessage.DuplicatePollOption)
+		}
+		seen[key] = true
+	}
+
+	query := `
+		INSERT INTO polls (post_id, multiple_choice, hide_results, closes_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := tx.ExecContext(ctx, query, postId, poll.MultipleChoice, poll.HideResults, poll.ClosesAt, now)
+	if err != nil {
+		return err
+	}
+	pollId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	args := make([]interface{}, 0, len(poll.Options)*3)
+	for i, option := range poll.Options {
+		args = append(args, pollId, i, strings.TrimSpace(option))
+	}
+	query = fmt.Sprintf(
+		"INSERT INTO poll_options (poll_id, position, text) VALUES %s",
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(poll.Options)), ", "),
+	)
+	_, err = tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// load the polls of a batch of posts as the viewer sees them. hidden results are left out
+// until the viewer votes or the poll closes, authors always see how their polls are doing
+func loadPolls(ctx context.Context, db *sql.DB, postIds []uint64, viewerId uint64) (map[uint64]*models.PollResponse, error) {
+	polls := make(map[uint64]*models.PollResponse)
+	if len(postIds) == 0 {
+		return polls, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			polls.id, polls.post_id, posts.user_id, polls.multiple_choice, polls.hide_results, polls.closes_at,
+			(SELECT COUNT(*) FROM poll_votes WHERE poll_votes.poll_id = polls.id)
+		FROM polls JOIN posts ON polls.post_id = posts.id
+		WHERE polls.post_id IN (%s)
+	`, placeholders(len(postIds)))
+	rows, err := db.QueryContext(ctx, query, idsToArgs(postIds)...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byPollId := make(map[uint64]*models.PollResponse)
+	authorIds := make(map[uint64]uint64)
+	for rows.Next() {
+		var postId, authorId, voterCount uint64
+		poll := &models.PollResponse{Options: []models.PollOptionResponse{}, MyVotes: []uint64{}}
+		if err := rows.Scan(&poll.ID, &postId, &authorId, &poll.MultipleChoice, &poll.HideResults, &poll.ClosesAt, &voterCount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		poll.Closed = !now.Before(poll.ClosesAt)
+		poll.VoterCount = &voterCount
+
+		polls[postId] = poll
+		byPollId[poll.ID] = poll
+		authorIds[poll.ID] = authorId
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(byPollId) == 0 {
+		return polls, nil
+	}
+
+	pollIds := make([]uint64, 0, len(byPollId))
+	for id := range byPollId {
+		pollIds = append(pollIds, id)
+	}
+
+	query = fmt.Sprintf(`
+		SELECT
+			poll_options.poll_id, poll_options.id, poll_options.text,
+			(SELECT COUNT(*) FROM poll_vote_options WHERE poll_vote_options.option_id = poll_options.id)
+		FROM poll_options
+		WHERE poll_options.poll_id IN (%s)
+		ORDER BY poll_options.poll_id, poll_options.position
+	`, placeholders(len(pollIds)))
+	rows, err = db.QueryContext(ctx, query, idsToArgs(pollIds)...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var pollId, votes uint64
+		var option models.PollOptionResponse
+		if err := rows.Scan(&pollId, &option.ID, &option.Text, &votes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		option.Votes = &votes
+		byPollId[pollId].Options = append(byPollId[pollId].Options, option)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if viewerId != 0 {
+		query = fmt.Sprintf(`
+			SELECT poll_id, option_id
+			FROM poll_vote_options
+			WHERE user_id = ? AND poll_id IN (%s)
+		`, placeholders(len(pollIds)))
+		rows, err = db.QueryContext(ctx, query, append([]interface{}{viewerId}, idsToArgs(pollIds)...)...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var pollId, optionId uint64
+			if err := rows.Scan(&pollId, &optionId); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			byPollId[pollId].MyVotes = append(byPollId[pollId].MyVotes, optionId)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for pollId, poll := range byPollId {
+		if !poll.HideResults || poll.Closed || len(poll.MyVotes) > 0 || (viewerId != 0 && authorIds[pollId] == viewerId) {
+			continue
+		}
+		poll.VoterCount = nil
+		for i := range poll.Options {
+			poll.Options[i].Votes = nil
+		}
+	}
+
+	return polls, nil
+}
@@ -3,8 +3,13 @@ package services
 import (
 	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
 	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/filters"
+	"chi-mysql-boilerplate/internal/utils/markdown"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
 	"time"
 )
 
@@ -16,17 +21,58 @@ func NewPostService(db *sql.DB) *PostService {
 	return &PostService{db: db}
 }
 
-func (p *PostService) Create(userId uint64, content string) (*models.Post, error) {
+func (p *PostService) Create(userId uint64, req models.PostRequest) (*models.Post, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
 	defer cancel()
 
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	creationTime := time.Now()
+	status, publishAt, err := resolvePublishing(req, "", nil, creationTime)
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = httpcommon.PostVisibility.Public
+	}
+
+	// quoting a repost quotes the original post
+	var quoteOfId *uint64
+	if req.QuoteOfID != nil {
+		originalId, err := resolveOriginalPost(ctx, tx, *req.QuoteOfID, userId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(httpcommon.ErrorMessage.InvalidQuotedPost)
+		}
+		if err != nil {
+			return nil, err
+		}
+		quoteOfId = &originalId
+	}
+
+	// drafts are checked once they're about to go out
+	var verdict *filters.Verdict
+	if status != httpcommon.PostStatus.Draft {
+		if verdict, err = filterPost(ctx, tx, 0, userId, req.Content); err != nil {
+			return nil, err
+		}
+		if verdict != nil && verdict.Action == filters.ActionHold {
+			status = httpcommon.PostStatus.Held
+		}
+	}
+
 	query := `
-		INSERT INTO posts (content, user_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO posts (content, content_html, user_id, status, publish_at, visibility, quote_of_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	creationTime := time.Now()
-	result, err := p.db.ExecContext(ctx, query, content, userId, creationTime, creationTime)
+	contentHtml := markdown.Render(req.Content)
+	result, err := tx.ExecContext(ctx, query, req.Content, contentHtml, userId, status, publishAt, visibility, quoteOfId, creationTime, creationTime)
 	if err != nil {
 		return nil, err
 	}
@@ -36,143 +82,665 @@ func (p *PostService) Create(userId uint64, content string) (*models.Post, error
 		return nil, err
 	}
 
+	if err = syncPostTags(ctx, tx, uint64(id), req.Content); err != nil {
+		return nil, err
+	}
+	if err = syncPostMentions(ctx, tx, uint64(id), req.Content); err != nil {
+		return nil, err
+	}
+	if err = syncPostLinks(ctx, tx, uint64(id), req.Content); err != nil {
+		return nil, err
+	}
+	if err = syncPostMedia(ctx, tx, uint64(id), userId, req.Media); err != nil {
+		return nil, err
+	}
+	if req.Poll != nil {
+		if err = createPoll(ctx, tx, uint64(id), *req.Poll, publishAt, creationTime); err != nil {
+			return nil, err
+		}
+	}
+	// drafts and scheduled posts notify the mentioned users once they're published
+	if status == httpcommon.PostStatus.Published {
+		if err = notifyPostMentions(ctx, tx, uint64(id), userId); err != nil {
+			return nil, err
+		}
+	}
+	if err = reportFilteredPost(ctx, tx, uint64(id), userId, verdict); err != nil {
+		return nil, err
+	}
+	if err = federatePostChange(ctx, tx, uint64(id), false); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	newPost := models.Post{
-		ID:        uint64(id),
-		Content:   content,
-		UserID:    userId,
-		CreatedAt: creationTime,
-		UpdatedAt: creationTime,
+		ID:          uint64(id),
+		Content:     req.Content,
+		ContentHTML: contentHtml,
+		UserID:      userId,
+		Status:      status,
+		PublishAt:   publishAt,
+		Visibility:  visibility,
+		QuoteOfID:   quoteOfId,
+		Version:     1,
+		CreatedAt:   creationTime,
+		UpdatedAt:   creationTime,
 	}
 
 	return &newPost, nil
 }
 
-func (p *PostService) GetAll() ([]*models.PostResponse, error) {
+func (p *PostService) GetAll(viewerId uint64) ([]*models.PostResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
 	defer cancel()
 
-	query := `
-		SELECT posts.id, content, user_id, username ,created_at, updated_at
+	visible, args := visibleTo(viewerId)
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM posts JOIN users
 		ON posts.user_id = users.id
-	`
-	rows, err := p.db.QueryContext(ctx, query)
+		WHERE posts.status = ? AND %s
+	`, postResponseColumns, visible)
+
+	return p.queryPosts(ctx, viewerId, query, append([]interface{}{httpcommon.PostStatus.Published}, args...)...)
+}
+
+func (p *PostService) GetByUserId(userId uint64, viewerId uint64) ([]*models.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	visible, args := visibleTo(viewerId)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts JOIN users
+		ON posts.user_id = users.id
+		WHERE user_id = ? AND %s
+		ORDER BY posts.pinned_position IS NULL, posts.pinned_position, posts.id
+	`, postResponseColumns, visible)
+
+	return p.queryPosts(ctx, viewerId, query, append([]interface{}{userId}, args...)...)
+}
+
+func (p *PostService) GetById(id uint64, viewerId uint64) (*models.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	// posts the viewer isn't allowed to see are reported as missing
+	visible, args := visibleTo(viewerId)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts JOIN users ON posts.user_id = users.id
+		WHERE posts.id = ? AND %s
+	`, postResponseColumns, visible)
+	posts, err := p.queryPosts(ctx, viewerId, query, append([]interface{}{id}, args...)...)
 	if err != nil {
 		return nil, err
 	}
+	if len(posts) == 0 {
+		return nil, sql.ErrNoRows
+	}
 
-	var posts []*models.PostResponse
-	for rows.Next() {
-		var post models.PostResponse
-		if err := rows.Scan(
-			&post.ID,
-			&post.Content,
-			&post.UserID,
-			&post.UserName,
-			&post.CreatedAt,
-			&post.UpdatedAt,
-		); err != nil {
-			return nil, err
+	return posts[0], nil
+}
+
+// media attachments are only replaced if the request lists them,
+// expectedVersions are the versions the client is willing to overwrite (none skips the check), returns the new version
+func (p *PostService) UpdateById(id uint64, req models.PostRequest, expectedVersions []uint64) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// lock the post so concurrent edits can't both archive the same version
+	query := `
+		SELECT content, user_id, status, publish_at, visibility, repost_of_id, hidden_at, version, updated_at
+		FROM posts
+		WHERE id = ?
+		FOR UPDATE
+	`
+	var oldContent, oldStatus, oldVisibility string
+	var authorId, version uint64
+	var oldPublishAt *time.Time
+	var repostOfId *uint64
+	var hiddenAt *time.Time
+	var oldUpdatedAt time.Time
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&oldContent, &authorId, &oldStatus, &oldPublishAt, &oldVisibility, &repostOfId, &hiddenAt, &version, &oldUpdatedAt); err != nil {
+		return 0, err
+	}
+	if repostOfId != nil {
+		return 0, errors.New(httpcommon.ErrorMessage.CannotEditRepost)
+	}
+	if len(expectedVersions) > 0 && !slices.Contains(expectedVersions, version) {
+		return 0, errors.New(httpcommon.ErrorMessage.VersionMismatch)
+	}
+
+	updateTime := time.Now()
+	status, publishAt, err := resolvePublishing(req, oldStatus, oldPublishAt, updateTime)
+	if err != nil {
+		return 0, err
+	}
+	visibility := oldVisibility
+	if req.Visibility != "" {
+		visibility = req.Visibility
+	}
+
+	// a held post stays held until a moderator releases it, but its author may still take it back to drafts.
+	// posts are only checked again when their content changes or they leave drafts
+	if oldStatus == httpcommon.PostStatus.Held && status != httpcommon.PostStatus.Draft {
+		status = httpcommon.PostStatus.Held
+	}
+	var verdict *filters.Verdict
+	if status != httpcommon.PostStatus.Draft && (oldContent != req.Content || oldStatus == httpcommon.PostStatus.Draft) {
+		if verdict, err = filterPost(ctx, tx, id, authorId, req.Content); err != nil {
+			return 0, err
 		}
+		if verdict != nil && verdict.Action == filters.ActionHold {
+			status = httpcommon.PostStatus.Held
+		}
+	}
 
-		posts = append(posts, &post)
+	// nothing to archive if the content didn't change
+	if oldContent != req.Content {
+		query = `
+			INSERT INTO post_revisions (post_id, content, created_at)
+			VALUES (?, ?, ?)
+		`
+		if _, err = tx.ExecContext(ctx, query, id, oldContent, oldUpdatedAt); err != nil {
+			return 0, err
+		}
 	}
 
-	return posts, nil
+	query = `
+		UPDATE posts
+		SET
+			content = ?,
+			content_html = ?,
+			status = ?,
+			publish_at = ?,
+			visibility = ?,
+			version = version + 1,
+			updated_at = ?
+		WHERE id = ?
+	`
+	if _, err = tx.ExecContext(ctx, query, req.Content, markdown.Render(req.Content), status, publishAt, visibility, updateTime, id); err != nil {
+		return 0, err
+	}
+
+	if err = syncPostTags(ctx, tx, id, req.Content); err != nil {
+		return 0, err
+	}
+	if err = syncPostMentions(ctx, tx, id, req.Content); err != nil {
+		return 0, err
+	}
+	if err = syncPostLinks(ctx, tx, id, req.Content); err != nil {
+		return 0, err
+	}
+	if err = syncPostMedia(ctx, tx, id, authorId, req.Media); err != nil {
+		return 0, err
+	}
+	if status == httpcommon.PostStatus.Published {
+		if err = notifyPostMentions(ctx, tx, id, authorId); err != nil {
+			return 0, err
+		}
+	}
+	if err = reportFilteredPost(ctx, tx, id, authorId, verdict); err != nil {
+		return 0, err
+	}
+	// only published posts stay pinned
+	if status != httpcommon.PostStatus.Published {
+		if err = unpinPost(ctx, tx, authorId, id); err != nil {
+			return 0, err
+		}
+	}
+	if err = federatePostChange(ctx, tx, id, isFederated(oldStatus, oldVisibility, repostOfId, hiddenAt)); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return version + 1, nil
 }
 
-func (p *PostService) GetByUserId(userId uint64) ([]*models.PostResponse, error) {
+// expectedVersions are the versions the client is willing to delete, none skips the check
+func (p *PostService) DeleteById(id uint64, expectedVersions []uint64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
 	defer cancel()
 
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = deletePost(ctx, tx, id, expectedVersions); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// delete a post along with the tags nobody uses anymore, and let remote followers know.
+// has to run inside a transaction since the post is locked first
+func deletePost(ctx context.Context, tx *sql.Tx, id uint64, expectedVersions []uint64) error {
 	query := `
-		SELECT posts.id, content, user_id, username, created_at, updated_at
-		FROM posts JOIN users
-		ON posts.user_id = users.id
-		WHERE user_id = ?
+		SELECT user_id, status, visibility, repost_of_id, hidden_at, version
+		FROM posts
+		WHERE id = ?
+		FOR UPDATE
+	`
+	var authorId, version uint64
+	var status, visibility string
+	var repostOfId *uint64
+	var hiddenAt *time.Time
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&authorId, &status, &visibility, &repostOfId, &hiddenAt, &version); err != nil {
+		return err
+	}
+	if len(expectedVersions) > 0 && !slices.Contains(expectedVersions, version) {
+		return errors.New(httpcommon.ErrorMessage.VersionMismatch)
+	}
+	if isFederated(status, visibility, repostOfId, hiddenAt) {
+		if err := federatePostDeletion(ctx, tx, id, authorId); err != nil {
+			return err
+		}
+	}
+	if err := unpinPost(ctx, tx, authorId, id); err != nil {
+		return err
+	}
+
+	// remember the post's tags, the links to them go away with the post
+	tagIds, err := queryIds(ctx, tx, "SELECT tag_id FROM post_tags WHERE post_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM posts
+		WHERE id = ?
+	`
+	if _, err = tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+
+	return purgeOrphanTags(ctx, tx, tagIds)
+}
+
+// fetch a page of the posts tagged with a hashtag, newest first
+func (p *PostService) GetByTag(tag string, viewerId uint64, page models.PageRequest) (*models.Page[*models.PostResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	join := `
+		JOIN post_tags ON post_tags.post_id = posts.id
+		JOIN tags ON post_tags.tag_id = tags.id
+	`
+
+	return p.queryPostPage(ctx, viewerId, join, "tags.name = ?", []interface{}{NormalizeTag(tag)}, page)
+}
+
+// fetch the latest public posts by a user (or by everyone when userId is 0) for a feed,
+// reposts are left out since feed readers have nothing to show for them
+func (p *PostService) GetFeed(userId uint64, limit int) ([]*models.PostResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	condition := "(? = 0 OR posts.user_id = ?) AND posts.repost_of_id IS NULL"
+	page, err := p.queryPostPage(ctx, 0, "", condition, []interface{}{userId, userId}, models.PageRequest{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Items, nil
+}
+
+// fetch a page of the posts by the user and everyone they follow, newest first
+func (p *PostService) GetTimeline(userId uint64, page models.PageRequest) (*models.Page[*models.PostResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	condition := `
+		posts.user_id = ?
+		OR posts.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)
 	`
-	rows, err := p.db.QueryContext(ctx, query, userId)
+
+	return p.queryPostPage(ctx, userId, "", condition, []interface{}{userId, userId}, page)
+}
+
+// work out the status and publish time a post should be saved with,
+// currentStatus is empty for posts that are being created
+func resolvePublishing(req models.PostRequest, currentStatus string, currentPublishAt *time.Time, now time.Time) (string, *time.Time, error) {
+	status := req.Status
+	if status == "" {
+		status = currentStatus
+	}
+	if status == "" {
+		status = httpcommon.PostStatus.Published
+	}
+
+	switch status {
+	case httpcommon.PostStatus.Published:
+		// a post keeps its original publish time through edits
+		if currentStatus == httpcommon.PostStatus.Published && currentPublishAt != nil {
+			return status, currentPublishAt, nil
+		}
+		return status, &now, nil
+	case httpcommon.PostStatus.Scheduled:
+		publishAt := req.PublishAt
+		if publishAt == nil && currentStatus == httpcommon.PostStatus.Scheduled {
+			publishAt = currentPublishAt
+		}
+		if publishAt == nil {
+			return "", nil, errors.New(httpcommon.ErrorMessage.MissingPublishAt)
+		}
+		if !publishAt.After(now) {
+			return "", nil, errors.New(httpcommon.ErrorMessage.PublishAtInPast)
+		}
+		return status, publishAt, nil
+	case httpcommon.PostStatus.Held:
+		// kept so a moderator releasing the post knows whether it was meant to be scheduled
+		return status, currentPublishAt, nil
+	default:
+		return status, nil, nil
+	}
+}
+
+// run a post through the content filters, postId is 0 for posts that are being created.
+// rejected posts end in an error, a verdict is returned for posts that were held or flagged
+func filterPost(ctx context.Context, tx *sql.Tx, postId uint64, userId uint64, content string) (*filters.Verdict, error) {
+	var accountCreatedAt time.Time
+	if err := tx.QueryRowContext(ctx, "SELECT created_at FROM users WHERE id = ?", userId).Scan(&accountCreatedAt); err != nil {
+		return nil, err
+	}
+
+	post := filters.Post{ID: postId, UserID: userId, Content: content, AccountCreatedAt: accountCreatedAt}
+	verdict, err := filters.Active().Check(ctx, tx, post)
+	if err != nil {
+		return nil, err
+	}
+	if verdict != nil && verdict.Action == filters.ActionReject {
+		return nil, errors.New(httpcommon.ErrorMessage.ContentRejected)
+	}
+
+	return verdict, nil
+}
+
+// put a post the content filters held or flagged in the moderation queue,
+// unless a filter already did and no moderator has looked at it yet
+func reportFilteredPost(ctx context.Context, tx *sql.Tx, postId uint64, authorId uint64, verdict *filters.Verdict) error {
+	if verdict == nil {
+		return nil
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM reports WHERE post_id = ? AND filter IS NOT NULL AND status != ?)"
+	if err := tx.QueryRowContext(ctx, query, postId, httpcommon.ReportStatus.Resolved).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	query = `
+		INSERT INTO reports (post_id, author_id, reason, details, filter, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := tx.ExecContext(ctx, query, postId, authorId, "spam", verdict.Reason, verdict.Filter, httpcommon.ReportStatus.Open, time.Now())
+	return err
+}
+
+// condition matching the posts a viewer is allowed to see (viewerId is 0 for anonymous viewers):
+// authors see all of their own posts, everyone else only published ones that weren't hidden
+// by a moderator or written by a suspended user, and that are public or followers-only ones
+// by someone they follow
+func visibleTo(viewerId uint64) (string, []interface{}) {
+	condition := `(
+		posts.user_id = ?
+		OR (posts.status = ? AND posts.hidden_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM users AS authors WHERE authors.id = posts.user_id AND authors.suspended_at IS NOT NULL
+		) AND (
+			posts.visibility = ?
+			OR (posts.visibility = ? AND EXISTS (
+				SELECT 1 FROM follows WHERE follows.follower_id = ? AND follows.followee_id = posts.user_id
+			))
+		))
+	)`
+
+	return condition, []interface{}{
+		viewerId,
+		httpcommon.PostStatus.Published,
+		httpcommon.PostVisibility.Public,
+		httpcommon.PostVisibility.Followers,
+		viewerId,
+	}
+}
+
+// columns selected by every query that builds a PostResponse
+const postResponseColumns = `
+	posts.id, posts.content, posts.content_html, posts.user_id, users.username,
+	posts.status, posts.publish_at, posts.visibility, posts.created_at, posts.updated_at,
+	posts.repost_of_id, posts.quote_of_id, posts.version, posts.hidden_at IS NOT NULL AS hidden, posts.view_count,
+	posts.pinned_position IS NOT NULL AS pinned,
+	(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id) AS comment_count,
+	(SELECT COUNT(*) FROM posts AS reposts WHERE reposts.repost_of_id = posts.id) AS repost_count,
+	(
+		SELECT COUNT(*) FROM posts AS quotes
+		WHERE quotes.quote_of_id = posts.id AND quotes.status = 'published'
+	) AS quote_count
+`
+
+// run a query selecting postResponseColumns, then attach everything else a PostResponse carries
+func (p *PostService) queryPosts(ctx context.Context, viewerId uint64, query string, args ...interface{}) ([]*models.PostResponse, error) {
+	posts, err := p.scanPosts(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.decoratePosts(ctx, posts, viewerId); err != nil {
+		return nil, err
+	}
+	if err = p.attachReferencedPosts(ctx, posts, viewerId); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// run a query selecting postResponseColumns into bare PostResponses
+func (p *PostService) scanPosts(ctx context.Context, query string, args ...interface{}) ([]*models.PostResponse, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var posts []*models.PostResponse
 	for rows.Next() {
 		var post models.PostResponse
+		var contentHtml sql.NullString
 		if err := rows.Scan(
 			&post.ID,
 			&post.Content,
+			&contentHtml,
 			&post.UserID,
 			&post.UserName,
+			&post.Status,
+			&post.PublishAt,
+			&post.Visibility,
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&post.RepostOfID,
+			&post.QuoteOfID,
+			&post.Version,
+			&post.Hidden,
+			&post.ViewCount,
+			&post.Pinned,
+			&post.CommentCount,
+			&post.RepostCount,
+			&post.QuoteCount,
 		); err != nil {
 			return nil, err
 		}
 
+		// posts from before content_html existed are rendered on the fly
+		post.ContentHTML = contentHtml.String
+		if !contentHtml.Valid {
+			post.ContentHTML = markdown.Render(post.Content)
+		}
+
 		posts = append(posts, &post)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return posts, nil
 }
 
-func (p *PostService) GetById(id uint64) (*models.PostResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
-	defer cancel()
-
-	query := `
-		SELECT posts.id, content, user_id, username, created_at, updated_at
+// fetch a page of published posts matching a condition that the viewer may see, newest first
+func (p *PostService) queryPostPage(ctx context.Context, viewerId uint64, join string, condition string, args []interface{}, page models.PageRequest) (*models.Page[*models.PostResponse], error) {
+	visible, visibleArgs := visibleTo(viewerId)
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM posts JOIN users ON posts.user_id = users.id
-		WHERE posts.id = ?
-	`
-	row := p.db.QueryRowContext(ctx, query, id)
+		%s
+		WHERE (%s) AND posts.status = ? AND %s AND (? = 0 OR posts.id < ?)
+		ORDER BY posts.id DESC
+		LIMIT ?
+	`, postResponseColumns, join, condition, visible)
+	args = append(args, httpcommon.PostStatus.Published)
+	args = append(args, visibleArgs...)
+	// grab one extra row to know if there's a next page
+	args = append(args, page.Cursor, page.Cursor, page.Limit+1)
 
-	var post models.PostResponse
-	if err := row.Scan(
-		&post.ID,
-		&post.Content,
-		&post.UserID,
-		&post.UserName,
-		&post.CreatedAt,
-		&post.UpdatedAt,
-	); err != nil {
+	posts, err := p.queryPosts(ctx, viewerId, query, args...)
+	if err != nil {
 		return nil, err
 	}
 
-	return &post, nil
+	result := &models.Page[*models.PostResponse]{Items: posts}
+	if result.Items == nil {
+		result.Items = []*models.PostResponse{}
+	}
+	if len(posts) > page.Limit {
+		result.Items = posts[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+
+	return result, nil
 }
 
-func (p *PostService) UpdateById(id uint64, updateContent string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
-	defer cancel()
+// attach the data kept in other tables to a batch of posts
+func (p *PostService) decoratePosts(ctx context.Context, posts []*models.PostResponse, viewerId uint64) error {
+	postIds := make([]uint64, len(posts))
+	for i, post := range posts {
+		postIds[i] = post.ID
+	}
 
-	query := `
-		UPDATE posts
-		SET
-			content = ?,
-			updated_at = ?
-		WHERE id = ?
-	`
-	_, err := p.db.ExecContext(ctx, query, updateContent, time.Now(), id)
+	reactions, err := loadReactionSummaries(ctx, p.db, postIds, viewerId)
+	if err != nil {
+		return err
+	}
+	mentions, err := loadMentionEntities(ctx, p.db, postIds)
+	if err != nil {
+		return err
+	}
+	bookmarked, err := loadBookmarkedPostIds(ctx, p.db, postIds, viewerId)
+	if err != nil {
+		return err
+	}
+	media, err := loadPostMedia(ctx, p.db, postIds)
+	if err != nil {
+		return err
+	}
+	reposted, err := loadRepostedPostIds(ctx, p.db, postIds, viewerId)
+	if err != nil {
+		return err
+	}
+	linkPreviews, err := loadLinkPreviews(ctx, p.db, postIds)
+	if err != nil {
+		return err
+	}
+	polls, err := loadPolls(ctx, p.db, postIds, viewerId)
 	if err != nil {
 		return err
 	}
 
+	for _, post := range posts {
+		post.Reactions = reactions[post.ID]
+		if post.Reactions == nil {
+			post.Reactions = []models.ReactionSummary{}
+		}
+		post.Mentions = mentions[post.ID]
+		if post.Mentions == nil {
+			post.Mentions = []models.MentionEntity{}
+		}
+		post.BookmarkedByMe = bookmarked[post.ID]
+		post.Media = media[post.ID]
+		if post.Media == nil {
+			post.Media = []models.PostMediaResponse{}
+		}
+		post.RepostedByMe = reposted[post.ID]
+		post.LinkPreviews = linkPreviews[post.ID]
+		if post.LinkPreviews == nil {
+			post.LinkPreviews = []models.LinkPreviewResponse{}
+		}
+		post.Poll = polls[post.ID]
+	}
+
 	return nil
 }
 
-func (p *PostService) DeleteById(id uint64) error {
-	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
-	defer cancel()
+// embed the posts that a batch of reposts and quotes point at, only one level deep
+// so a quote of a quote doesn't drag in the whole chain
+func (p *PostService) attachReferencedPosts(ctx context.Context, posts []*models.PostResponse, viewerId uint64) error {
+	referencedIds := []uint64{}
+	for _, post := range posts {
+		if post.RepostOfID != nil {
+			referencedIds = append(referencedIds, *post.RepostOfID)
+		} else if post.QuoteOfID != nil {
+			referencedIds = append(referencedIds, *post.QuoteOfID)
+		}
+	}
+	if len(referencedIds) == 0 {
+		return nil
+	}
 
-	query := `
-		DELETE FROM posts
-		WHERE id = ?
-	`
-	_, err := p.db.ExecContext(ctx, query, id)
+	// deleted posts and ones the viewer can't see are simply left out
+	visible, args := visibleTo(viewerId)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts JOIN users ON posts.user_id = users.id
+		WHERE posts.id IN (%s) AND posts.status = ? AND %s
+	`, postResponseColumns, placeholders(len(referencedIds)), visible)
+	args = append(append(idsToArgs(referencedIds), httpcommon.PostStatus.Published), args...)
+	referenced, err := p.scanPosts(ctx, query, args...)
 	if err != nil {
 		return err
 	}
+	if err = p.decoratePosts(ctx, referenced, viewerId); err != nil {
+		return err
+	}
+
+	byId := make(map[uint64]*models.PostResponse, len(referenced))
+	for _, post := range referenced {
+		byId[post.ID] = post
+	}
+	for _, post := range posts {
+		if post.RepostOfID != nil {
+			post.ReferencedPost = byId[*post.RepostOfID]
+		} else if post.QuoteOfID != nil {
+			post.ReferencedPost = byId[*post.QuoteOfID]
+		}
+	}
 
 	return nil
 }
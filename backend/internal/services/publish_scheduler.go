@@ -0,0 +1,125 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PublishScheduler struct {
+	db *sql.DB
+}
+
+func NewPublishScheduler(db *sql.DB) *PublishScheduler {
+	return &PublishScheduler{db: db}
+}
+
+// publish due posts on every tick until the context is cancelled
+func (ps *PublishScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(httpcommon.SchedulerConstants.PublishInterval)
+	defer ticker.Stop()
+
+	for {
+		// catch up on anything that came due while the server was down before waiting
+		for {
+			published, err := ps.PublishDue(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					helpers.MessageLogs.ErrorLog.Println(err)
+				}
+				break
+			}
+			if published < httpcommon.SchedulerConstants.PublishBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish a batch of scheduled posts whose publish time has passed, returns how many were published.
+// the rows are locked for the duration of the transaction so that several instances
+// running the scheduler never publish (and notify about) the same post twice, and rows
+// another instance already holds are skipped rather than waited on (needs MySQL 8)
+func (ps *PublishScheduler) PublishDue(ctx context.Context) (int, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, user_id
+		FROM posts
+		WHERE status = ? AND publish_at <= ?
+		ORDER BY publish_at
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.QueryContext(ctx, query, httpcommon.PostStatus.Scheduled, time.Now(), httpcommon.SchedulerConstants.PublishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	type duePost struct {
+		id       uint64
+		authorId uint64
+	}
+	var due []duePost
+	for rows.Next() {
+		var post duePost
+		if err := rows.Scan(&post.id, &post.authorId); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, post)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, post := range due {
+		// the status check guards against the author having unscheduled the post in the meantime,
+		// and publishing isn't an edit so updated_at is kept as it is
+		result, err := tx.ExecContext(
+			ctx,
+			"UPDATE posts SET status = ?, updated_at = updated_at WHERE id = ? AND status = ?",
+			httpcommon.PostStatus.Published,
+			post.id,
+			httpcommon.PostStatus.Scheduled,
+		)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if affected == 0 {
+			continue
+		}
+
+		if err = notifyPostMentions(ctx, tx, post.id, post.authorId); err != nil {
+			return 0, err
+		}
+		if err = federatePostChange(ctx, tx, post.id, false); err != nil {
+			return 0, err
+		}
+		published++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
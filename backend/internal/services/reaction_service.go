@@ -0,0 +1,185 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ReactionService struct {
+	db *sql.DB
+}
+
+func NewReactionService(db *sql.DB) *ReactionService {
+	return &ReactionService{db: db}
+}
+
+// reacting twice with the same emoji is a no-op thanks to the unique key
+func (rs *ReactionService) React(postId uint64, userId uint64, emoji string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	emoji, ok := NormalizeEmoji(emoji)
+	if !ok {
+		return errors.New(httpcommon.ErrorMessage.InvalidReaction)
+	}
+
+	// make sure the post exists before reacting to it
+	var authorId uint64
+	if err := rs.db.QueryRowContext(ctx, "SELECT user_id FROM posts WHERE id = ?", postId).Scan(&authorId); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT IGNORE INTO post_reactions (post_id, user_id, emoji, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+	result, err := rs.db.ExecContext(ctx, query, postId, userId, emoji, time.Now())
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	return notifyOnce(ctx, rs.db, models.Notification{
+		Kind:    httpcommon.NotificationKind.Reaction,
+		ActorID: userId,
+		PostID:  &postId,
+	}, authorId)
+}
+
+func (rs *ReactionService) Unreact(postId uint64, userId uint64, emoji string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	emoji, ok := NormalizeEmoji(emoji)
+	if !ok {
+		return errors.New(httpcommon.ErrorMessage.InvalidReaction)
+	}
+
+	query := `
+		DELETE FROM post_reactions
+		WHERE post_id = ? AND user_id = ? AND emoji = ?
+	`
+	_, err := rs.db.ExecContext(ctx, query, postId, userId, emoji)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// list who reacted to a post, optionally only with a specific emoji
+func (rs *ReactionService) GetReactors(postId uint64, emoji string, page models.PageRequest) (*models.Page[*models.ReactorResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT post_reactions.id, user_id, username, emoji, created_at
+		FROM post_reactions JOIN users ON post_reactions.user_id = users.id
+		WHERE post_id = ? AND post_reactions.id > ?
+	`
+	args := []interface{}{postId, page.Cursor}
+	if emoji != "" {
+		normalized, ok := NormalizeEmoji(emoji)
+		if !ok {
+			return nil, errors.New(httpcommon.ErrorMessage.InvalidReaction)
+		}
+		query += " AND emoji = ?"
+		args = append(args, normalized)
+	}
+	// grab one extra row to know if there's a next page
+	query += " ORDER BY post_reactions.id LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	rows, err := rs.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactors := []*models.ReactorResponse{}
+	for rows.Next() {
+		var reactor models.ReactorResponse
+		if err := rows.Scan(
+			&reactor.ID,
+			&reactor.UserID,
+			&reactor.UserName,
+			&reactor.Emoji,
+			&reactor.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		reactors = append(reactors, &reactor)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[*models.ReactorResponse]{Items: reactors}
+	if len(reactors) > page.Limit {
+		result.Items = reactors[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+
+	return result, nil
+}
+
+// the emoji presentation selector (U+FE0F)
+const variationSelector = "\ufe0f"
+
+// helper function to match an emoji against the allowed set,
+// ignoring the variation selector that some clients leave out
+func NormalizeEmoji(emoji string) (string, bool) {
+	stripped := strings.ReplaceAll(emoji, variationSelector, "")
+	for _, allowed := range httpcommon.ReactionConstants.AllowedEmoji {
+		if strings.ReplaceAll(allowed, variationSelector, "") == stripped {
+			return allowed, true
+		}
+	}
+
+	return "", false
+}
+
+// aggregate the reactions on a batch of posts, flagging the ones made by the viewer
+func loadReactionSummaries(ctx context.Context, db *sql.DB, postIds []uint64, viewerId uint64) (map[uint64][]models.ReactionSummary, error) {
+	summaries := make(map[uint64][]models.ReactionSummary, len(postIds))
+	if len(postIds) == 0 {
+		return summaries, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT post_id, emoji, COUNT(*), MAX(user_id = ?)
+		FROM post_reactions
+		WHERE post_id IN (%s)
+		GROUP BY post_id, emoji
+		ORDER BY post_id, MIN(id)
+	`, placeholders(len(postIds)))
+	args := append([]interface{}{viewerId}, idsToArgs(postIds)...)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId uint64
+		var summary models.ReactionSummary
+		if err := rows.Scan(&postId, &summary.Emoji, &summary.Count, &summary.ReactedByMe); err != nil {
+			return nil, err
+		}
+
+		summaries[postId] = append(summaries[postId], summary)
+	}
+
+	return summaries, rows.Err()
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ReportService struct {
+	db *sql.DB
+}
+
+func NewReportService(db *sql.DB) *ReportService {
+	return &ReportService{db: db}
+}
+
+// each user can report a post once, the unique key on (post_id, reporter_id) enforces it
+func (rs *ReportService) Create(postId uint64, reporterId uint64, req models.ReportRequest) (*models.ReportResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var authorId uint64
+	if err := rs.db.QueryRowContext(ctx, "SELECT user_id FROM posts WHERE id = ?", postId).Scan(&authorId); err != nil {
+		return nil, err
+	}
+	if authorId == reporterId {
+		return nil, errors.New(httpcommon.ErrorMessage.CannotReportOwnPost)
+	}
+
+	var details *string
+	if req.Details != "" {
+		details = &req.Details
+	}
+
+	query := `
+		INSERT IGNORE INTO reports (post_id, author_id, reporter_id, reason, details, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := rs.db.ExecContext(ctx, query, postId, authorId, reporterId, req.Reason, details, httpcommon.ReportStatus.Open, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, errors.New(httpcommon.ErrorMessage.AlreadyReported)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return getReport(ctx, rs.db, uint64(id))
+}
+
+// fetch a page of the moderation queue, oldest first. an empty status lists every report
+// that still needs a decision, whether or not someone claimed it
+func (rs *ReportService) GetQueue(status string, page models.PageRequest) (*models.Page[*models.ReportResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	condition := "reports.status IN (?, ?)"
+	args := []interface{}{httpcommon.ReportStatus.Open, httpcommon.ReportStatus.Claimed}
+	if status != "" {
+		condition = "reports.status = ?"
+		args = []interface{}{status}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		WHERE %s AND (? = 0 OR reports.id > ?)
+		ORDER BY reports.id
+		LIMIT ?
+	`, reportColumns, reportJoins, condition)
+	// grab one extra row to know if there's a next page
+	args = append(args, page.Cursor, page.Cursor, page.Limit+1)
+
+	reports, err := scanReports(ctx, rs.db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[*models.ReportResponse]{Items: reports}
+	if len(reports) > page.Limit {
+		result.Items = reports[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+
+	return result, nil
+}
+
+// claiming tells other moderators that someone is looking into a report,
+// claiming a report twice is a no-op
+func (rs *ReportService) Claim(reportId uint64, moderatorId uint64) (*models.ReportResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := rs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = lockReport(ctx, tx, reportId, moderatorId); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE reports
+		SET status = ?, moderator_id = ?, claimed_at = COALESCE(claimed_at, ?)
+		WHERE id = ?
+	`
+	if _, err = tx.ExecContext(ctx, query, httpcommon.ReportStatus.Claimed, moderatorId, time.Now(), reportId); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getReport(ctx, rs.db, reportId)
+}
+
+// apply a moderation action and resolve the report. anything but dismissing deals with the post
+// itself, so the other reports about it are resolved along with this one.
+// every reporter involved is notified, without being told what was done
+func (rs *ReportService) Resolve(reportId uint64, moderatorId uint64, req models.ResolveReportRequest) (*models.ReportResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := rs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := lockReport(ctx, tx, reportId, moderatorId)
+	if err != nil {
+		return nil, err
+	}
+
+	reportIds := []uint64{reportId}
+	if req.Action != httpcommon.ModerationAction.Dismiss && report.postId != nil {
+		query := `
+			SELECT id FROM reports
+			WHERE post_id = ? AND status != ?
+			FOR UPDATE
+		`
+		if reportIds, err = queryIds(ctx, tx, query, *report.postId, httpcommon.ReportStatus.Resolved); err != nil {
+			return nil, err
+		}
+	}
+	query := fmt.Sprintf("SELECT DISTINCT reporter_id FROM reports WHERE id IN (%s) AND reporter_id IS NOT NULL", placeholders(len(reportIds)))
+	reporterIds, err := queryIds(ctx, tx, query, idsToArgs(reportIds)...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	notifiedPostId := report.postId
+	switch req.Action {
+	case httpcommon.ModerationAction.Dismiss:
+		// dismissing a content filter's report on a post it held lets the post out
+		if report.filter != nil && report.postId != nil {
+			if err = releasePost(ctx, tx, *report.postId, now); err != nil {
+				return nil, err
+			}
+		}
+	case httpcommon.ModerationAction.HidePost:
+		if report.postId != nil {
+			if err = hidePost(ctx, tx, *report.postId, now); err != nil {
+				return nil, err
+			}
+		}
+	case httpcommon.ModerationAction.DeletePost:
+		if report.postId != nil {
+			if err = deletePost(ctx, tx, *report.postId, nil); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			notifiedPostId = nil
+		}
+	case httpcommon.ModerationAction.SuspendAuthor:
+		// signing the author out keeps them from getting new access tokens,
+		// and the one they hold is turned away by the RejectSuspended middleware
+		query := `
+			UPDATE users
+			SET suspended_at = ?, refresh_token = NULL
+			WHERE id = ? AND suspended_at IS NULL
+		`
+		if _, err = tx.ExecContext(ctx, query, now, report.authorId); err != nil {
+			return nil, err
+		}
+	}
+
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+	query = fmt.Sprintf(`
+		UPDATE reports
+		SET status = ?, moderator_id = ?, action = ?, resolution_note = ?, claimed_at = COALESCE(claimed_at, ?), resolved_at = ?
+		WHERE id IN (%s)
+	`, placeholders(len(reportIds)))
+	args := append([]interface{}{httpcommon.ReportStatus.Resolved, moderatorId, req.Action, note, now, now}, idsToArgs(reportIds)...)
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	notification := models.Notification{
+		Kind:    httpcommon.NotificationKind.Moderation,
+		ActorID: moderatorId,
+		PostID:  notifiedPostId,
+	}
+	if err = createNotifications(ctx, tx, notification, reporterIds); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getReport(ctx, rs.db, reportId)
+}
+
+type lockedReport struct {
+	postId   *uint64
+	authorId uint64
+	filter   *string
+}
+
+// lock a report that the moderator may act on: one that isn't resolved yet
+// and isn't claimed by another moderator
+func lockReport(ctx context.Context, tx *sql.Tx, reportId uint64, moderatorId uint64) (*lockedReport, error) {
+	query := `
+		SELECT post_id, author_id, filter, status, moderator_id
+		FROM reports
+		WHERE id = ?
+		FOR UPDATE
+	`
+	var report lockedReport
+	var status string
+	var claimedBy *uint64
+	if err := tx.QueryRowContext(ctx, query, reportId).Scan(&report.postId, &report.authorId, &report.filter, &status, &claimedBy); err != nil {
+		return nil, err
+	}
+	if status == httpcommon.ReportStatus.Resolved {
+		return nil, errors.New(httpcommon.ErrorMessage.ReportResolved)
+	}
+	if status == httpcommon.ReportStatus.Claimed && claimedBy != nil && *claimedBy != moderatorId {
+		return nil, errors.New(httpcommon.ErrorMessage.ReportClaimed)
+	}
+
+	return &report, nil
+}
+
+// hide a post from everyone but its author, hiding isn't an edit so updated_at is kept as it is
+func hidePost(ctx context.Context, tx *sql.Tx, postId uint64, now time.Time) error {
+	query := `
+		SELECT status, visibility, repost_of_id, hidden_at
+		FROM posts
+		WHERE id = ?
+		FOR UPDATE
+	`
+	var status, visibility string
+	var repostOfId *uint64
+	var hiddenAt *time.Time
+	if err := tx.QueryRowContext(ctx, query, postId).Scan(&status, &visibility, &repostOfId, &hiddenAt); err != nil {
+		return err
+	}
+	wasFederated := isFederated(status, visibility, repostOfId, hiddenAt)
+
+	if _, err := tx.ExecContext(ctx, "UPDATE posts SET hidden_at = ?, updated_at = updated_at WHERE id = ? AND hidden_at IS NULL", now, postId); err != nil {
+		return err
+	}
+
+	// remote instances have no way to hide it, so they're told it's gone
+	return federatePostChange(ctx, tx, postId, wasFederated)
+}
+
+// publish a post that was held by a content filter, or schedule it if it was meant to go out later.
+// posts that aren't held anymore are left alone
+func releasePost(ctx context.Context, tx *sql.Tx, postId uint64, now time.Time) error {
+	query := `
+		SELECT user_id, status, publish_at
+		FROM posts
+		WHERE id = ?
+		FOR UPDATE
+	`
+	var authorId uint64
+	var status string
+	var publishAt *time.Time
+	if err := tx.QueryRowContext(ctx, query, postId).Scan(&authorId, &status, &publishAt); err != nil {
+		return err
+	}
+	if status != httpcommon.PostStatus.Held {
+		return nil
+	}
+
+	if publishAt != nil && publishAt.After(now) {
+		_, err := tx.ExecContext(ctx, "UPDATE posts SET status = ?, updated_at = updated_at WHERE id = ?", httpcommon.PostStatus.Scheduled, postId)
+		return err
+	}
+
+	query = "UPDATE posts SET status = ?, publish_at = ?, updated_at = updated_at WHERE id = ?"
+	if _, err := tx.ExecContext(ctx, query, httpcommon.PostStatus.Published, now, postId); err != nil {
+		return err
+	}
+	if err := notifyPostMentions(ctx, tx, postId, authorId); err != nil {
+		return err
+	}
+	return federatePostChange(ctx, tx, postId, false)
+}
+
+const reportColumns = `
+	reports.id, reports.post_id, posts.content, reports.author_id, authors.username,
+	reports.reporter_id, reporters.username, reports.filter, reports.reason, reports.details, reports.status,
+	reports.moderator_id, reports.action, reports.resolution_note,
+	reports.created_at, reports.claimed_at, reports.resolved_at
+`
+
+const reportJoins = `
+	FROM reports
+	LEFT JOIN posts ON reports.post_id = posts.id
+	JOIN users AS authors ON reports.author_id = authors.id
+	LEFT JOIN users AS reporters ON reports.reporter_id = reporters.id
+`
+
+func getReport(ctx context.Context, db dbExecutor, reportId uint64) (*models.ReportResponse, error) {
+	query := fmt.Sprintf("SELECT %s %s WHERE reports.id = ?", reportColumns, reportJoins)
+	reports, err := scanReports(ctx, db, query, reportId)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return reports[0], nil
+}
+
+func scanReports(ctx context.Context, db dbExecutor, query string, args ...interface{}) ([]*models.ReportResponse, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*models.ReportResponse{}
+	for rows.Next() {
+		var report models.ReportResponse
+		if err := rows.Scan(
+			&report.ID,
+			&report.PostID,
+			&report.PostContent,
+			&report.AuthorID,
+			&report.AuthorName,
+			&report.ReporterID,
+			&report.ReporterName,
+			&report.Filter,
+			&report.Reason,
+			&report.Details,
+			&report.Status,
+			&report.ModeratorID,
+			&report.Action,
+			&report.ResolutionNote,
+			&report.CreatedAt,
+			&report.ClaimedAt,
+			&report.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		reports = append(reports, &report)
+	}
+
+	return reports, rows.Err()
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type RepostService struct {
+	db *sql.DB
+}
+
+func NewRepostService(db *sql.DB) *RepostService {
+	return &RepostService{db: db}
+}
+
+// reposting a repost shares the original post, and reposting the same post twice
+// is a no-op thanks to the unique key on (user_id, repost_of_id)
+func (rs *RepostService) Repost(userId uint64, postId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	originalId, err := resolveOriginalPost(ctx, rs.db, postId, userId)
+	if err != nil {
+		return err
+	}
+
+	// sharing a restricted post would show it to people its author didn't pick
+	var visibility string
+	if err = rs.db.QueryRowContext(ctx, "SELECT visibility FROM posts WHERE id = ?", originalId).Scan(&visibility); err != nil {
+		return err
+	}
+	if visibility != httpcommon.PostVisibility.Public {
+		return errors.New(httpcommon.ErrorMessage.CannotRepost)
+	}
+
+	query := `
+		INSERT IGNORE INTO posts (content, content_html, user_id, status, publish_at, visibility, repost_of_id, created_at, updated_at)
+		VALUES ('', '', ?, ?, ?, ?, ?, ?, ?)
+	`
+	creationTime := time.Now()
+	_, err = rs.db.ExecContext(
+		ctx,
+		query,
+		userId,
+		httpcommon.PostStatus.Published,
+		creationTime,
+		httpcommon.PostVisibility.Public,
+		originalId,
+		creationTime,
+		creationTime,
+	)
+
+	return err
+}
+
+// undo a repost, postId may be either the original post or the repost itself
+func (rs *RepostService) Unrepost(userId uint64, postId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM posts
+		WHERE user_id = ? AND repost_of_id IS NOT NULL AND (repost_of_id = ? OR id = ?)
+	`
+	_, err := rs.db.ExecContext(ctx, query, userId, postId, postId)
+
+	return err
+}
+
+// find the post a repost or quote should point at: the post itself,
+// or the original when it's a repost, as long as the user can see it
+func resolveOriginalPost(ctx context.Context, db dbExecutor, postId uint64, userId uint64) (uint64, error) {
+	visible, args := visibleTo(userId)
+	query := fmt.Sprintf(`
+		SELECT COALESCE(posts.repost_of_id, posts.id)
+		FROM posts
+		WHERE posts.id = ? AND posts.status = ? AND %s
+	`, visible)
+
+	var originalId uint64
+	err := db.QueryRowContext(ctx, query, append([]interface{}{postId, httpcommon.PostStatus.Published}, args...)...).Scan(&originalId)
+	if err != nil {
+		return 0, err
+	}
+
+	return originalId, nil
+}
+
+// find which of a batch of posts the viewer has reposted
+func loadRepostedPostIds(ctx context.Context, db *sql.DB, postIds []uint64, viewerId uint64) (map[uint64]bool, error) {
+	reposted := make(map[uint64]bool)
+	if len(postIds) == 0 || viewerId == 0 {
+		return reposted, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT repost_of_id
+		FROM posts
+		WHERE user_id = ? AND repost_of_id IN (%s)
+	`, placeholders(len(postIds)))
+	ids, err := queryIds(ctx, db, query, append([]interface{}{viewerId}, idsToArgs(postIds)...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		reposted[id] = true
+	}
+
+	return reposted, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/utils/diff"
+	"context"
+	"database/sql"
+)
+
+type RevisionService struct {
+	db *sql.DB
+}
+
+func NewRevisionService(db *sql.DB) *RevisionService {
+	return &RevisionService{db: db}
+}
+
+// revisions are written by PostService.UpdateById, this service only reads them
+func (rs *RevisionService) GetByPostId(postId uint64) ([]*models.PostRevisionResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, post_id, content, created_at
+		FROM post_revisions
+		WHERE post_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := rs.db.QueryContext(ctx, query, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.PostRevisionResponse{}
+	for rows.Next() {
+		var revision models.PostRevisionResponse
+		if err := rows.Scan(
+			&revision.ID,
+			&revision.PostID,
+			&revision.Content,
+			&revision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// fetch a single revision along with a line diff from it to the post's current content
+func (rs *RevisionService) GetById(postId uint64, revisionId uint64) (*models.PostRevisionDetailResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT post_revisions.id, post_id, post_revisions.content, post_revisions.created_at, posts.content
+		FROM post_revisions JOIN posts ON post_revisions.post_id = posts.id
+		WHERE post_revisions.id = ? AND post_id = ?
+	`
+	row := rs.db.QueryRowContext(ctx, query, revisionId, postId)
+
+	var revision models.PostRevisionDetailResponse
+	var currentContent string
+	if err := row.Scan(
+		&revision.ID,
+		&revision.PostID,
+		&revision.Content,
+		&revision.CreatedAt,
+		&currentContent,
+	); err != nil {
+		return nil, err
+	}
+
+	revision.Diff = diff.Lines(revision.Content, currentContent)
+
+	return &revision, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"chi-mysql-boilerplate/internal/utils/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type TagService struct {
+	db *sql.DB
+}
+
+func NewTagService(db *sql.DB) *TagService {
+	return &TagService{db: db}
+}
+
+// autocomplete tags by prefix, most used first. only posts anyone may read are counted,
+// so tags used solely in drafts, restricted or moderated posts don't show up at all
+func (t *TagService) Search(prefix string, limit int) ([]*models.TagResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	visible, args := visibleTo(0)
+	query := fmt.Sprintf(`
+		SELECT name, COUNT(post_tags.post_id) AS post_count
+		FROM tags
+		JOIN post_tags ON tags.id = post_tags.tag_id
+		JOIN posts ON post_tags.post_id = posts.id
+		WHERE name LIKE ? AND %s
+		GROUP BY tags.id, name
+		ORDER BY post_count DESC, name
+		LIMIT ?
+	`, visible)
+	pattern := escapeLike(NormalizeTag(prefix)) + "%"
+	args = append([]interface{}{pattern}, args...)
+	rows, err := t.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.TagResponse{}
+	for rows.Next() {
+		var tag models.TagResponse
+		if err := rows.Scan(&tag.Name, &tag.PostCount); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, &tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// helper function to turn user input ("#GoLang") into the stored form of a tag ("golang")
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// make the tags linked to a post match the hashtags in its content,
+// has to run inside the transaction that writes the content
+func syncPostTags(ctx context.Context, tx *sql.Tx, postId uint64, content string) error {
+	names := entities.Hashtags(content)
+
+	oldTagIds, err := queryIds(ctx, tx, "SELECT tag_id FROM post_tags WHERE post_id = ?", postId)
+	if err != nil {
+		return err
+	}
+
+	var newTagIds []uint64
+	if len(names) > 0 {
+		args := make([]interface{}, len(names))
+		for i, name := range names {
+			args[i] = name
+		}
+
+		query := fmt.Sprintf("INSERT IGNORE INTO tags (name) VALUES %s", strings.TrimSuffix(strings.Repeat("(?), ", len(names)), ", "))
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf("SELECT id FROM tags WHERE name IN (%s)", placeholders(len(names)))
+		if newTagIds, err = queryIds(ctx, tx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM post_tags WHERE post_id = ?", postId); err != nil {
+		return err
+	}
+	if len(newTagIds) > 0 {
+		values := strings.TrimSuffix(strings.Repeat("(?, ?), ", len(newTagIds)), ", ")
+		args := make([]interface{}, 0, 2*len(newTagIds))
+		for _, tagId := range newTagIds {
+			args = append(args, postId, tagId)
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO post_tags (post_id, tag_id) VALUES "+values, args...); err != nil {
+			return err
+		}
+	}
+
+	return purgeOrphanTags(ctx, tx, oldTagIds)
+}
+
+// remove the given tags if no post uses them anymore
+func purgeOrphanTags(ctx context.Context, tx *sql.Tx, tagIds []uint64) error {
+	if len(tagIds) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		DELETE tags FROM tags
+		LEFT JOIN post_tags ON tags.id = post_tags.tag_id
+		WHERE post_tags.tag_id IS NULL AND tags.id IN (%s)
+	`, placeholders(len(tagIds)))
+	_, err := tx.ExecContext(ctx, query, idsToArgs(tagIds)...)
+
+	return err
+}
+
+// helper function to escape the wildcards in a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
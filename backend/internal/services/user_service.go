@@ -0,0 +1,199 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type UserService struct {
+	db *sql.DB
+}
+
+func NewUserService(db *sql.DB) *UserService {
+	return &UserService{db: db}
+}
+
+func (u *UserService) GetIdByUsername(username string) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var id uint64
+	if err := u.db.QueryRowContext(ctx, "SELECT id FROM users WHERE username = ?", username).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (u *UserService) IsAdmin(userId uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var role string
+	if err := u.db.QueryRowContext(ctx, "SELECT role FROM users WHERE id = ?", userId).Scan(&role); err != nil {
+		return false, err
+	}
+
+	return role == httpcommon.UserRole.Admin, nil
+}
+
+// admins can do everything moderators can
+func (u *UserService) IsModerator(userId uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var role string
+	if err := u.db.QueryRowContext(ctx, "SELECT role FROM users WHERE id = ?", userId).Scan(&role); err != nil {
+		return false, err
+	}
+
+	return role == httpcommon.UserRole.Moderator || role == httpcommon.UserRole.Admin, nil
+}
+
+func (u *UserService) IsSuspended(userId uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	var suspended bool
+	if err := u.db.QueryRowContext(ctx, "SELECT suspended_at IS NOT NULL FROM users WHERE id = ?", userId).Scan(&suspended); err != nil {
+		return false, err
+	}
+
+	return suspended, nil
+}
+
+func (u *UserService) GetProfile(userId uint64, viewerId uint64) (*models.UserProfileResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			username,
+			(SELECT COUNT(*) FROM follows WHERE followee_id = users.id),
+			(SELECT COUNT(*) FROM follows WHERE follower_id = users.id),
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND followee_id = users.id)
+		FROM users
+		WHERE id = ?
+	`
+	row := u.db.QueryRowContext(ctx, query, viewerId, userId)
+
+	var profile models.UserProfileResponse
+	if err := row.Scan(
+		&profile.ID,
+		&profile.UserName,
+		&profile.FollowerCount,
+		&profile.FollowingCount,
+		&profile.FollowedByMe,
+	); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// following someone twice is a no-op thanks to the unique key
+func (u *UserService) Follow(followerId uint64, followeeId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	if followerId == followeeId {
+		return errors.New(httpcommon.ErrorMessage.CannotFollowSelf)
+	}
+
+	// make sure the user exists before following them
+	var exists int
+	if err := u.db.QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = ?", followeeId).Scan(&exists); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT IGNORE INTO follows (follower_id, followee_id, created_at)
+		VALUES (?, ?, ?)
+	`
+	result, err := u.db.ExecContext(ctx, query, followerId, followeeId, time.Now())
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	return notifyOnce(ctx, u.db, models.Notification{
+		Kind:    httpcommon.NotificationKind.Follow,
+		ActorID: followerId,
+	}, followeeId)
+}
+
+func (u *UserService) Unfollow(followerId uint64, followeeId uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM follows
+		WHERE follower_id = ? AND followee_id = ?
+	`
+	_, err := u.db.ExecContext(ctx, query, followerId, followeeId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fetch a page of the users following the given user, most recent first
+func (u *UserService) GetFollowers(userId uint64, page models.PageRequest) (*models.Page[*models.FollowResponse], error) {
+	return u.queryFollows(userId, "followee_id", "follower_id", page)
+}
+
+// fetch a page of the users the given user follows, most recent first
+func (u *UserService) GetFollowing(userId uint64, page models.PageRequest) (*models.Page[*models.FollowResponse], error) {
+	return u.queryFollows(userId, "follower_id", "followee_id", page)
+}
+
+func (u *UserService) queryFollows(userId uint64, filterColumn string, userColumn string, page models.PageRequest) (*models.Page[*models.FollowResponse], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT follows.id, users.id, users.username, follows.created_at
+		FROM follows JOIN users ON follows.%s = users.id
+		WHERE follows.%s = ? AND (? = 0 OR follows.id < ?)
+		ORDER BY follows.id DESC
+		LIMIT ?
+	`, userColumn, filterColumn)
+	// grab one extra row to know if there's a next page
+	rows, err := u.db.QueryContext(ctx, query, userId, page.Cursor, page.Cursor, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []*models.FollowResponse{}
+	for rows.Next() {
+		var follow models.FollowResponse
+		if err := rows.Scan(&follow.ID, &follow.UserID, &follow.UserName, &follow.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		follows = append(follows, &follow)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[*models.FollowResponse]{Items: follows}
+	if len(follows) > page.Limit {
+		result.Items = follows[:page.Limit]
+		nextCursor := result.Items[page.Limit-1].ID
+		result.NextCursor = &nextCursor
+	}
+
+	return result, nil
+}
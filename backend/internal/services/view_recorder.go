@@ -0,0 +1,143 @@
+package services
+
+import (
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/helpers"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// counts post views in memory and writes them out in batches, so reading a post
+// doesn't cost a write. views are deduplicated per instance, a viewer switching
+// between instances behind a load balancer may be counted once on each
+type ViewRecorder struct {
+	db *sql.DB
+
+	mu sync.Mutex
+	// when each viewer's last counted view of a post happened
+	seen map[postViewer]time.Time
+	// views counted since the last flush
+	pending map[postDay]uint64
+}
+
+type postViewer struct {
+	postId uint64
+	viewer string
+}
+
+type postDay struct {
+	postId uint64
+	day    string
+}
+
+func NewViewRecorder(db *sql.DB) *ViewRecorder {
+	return &ViewRecorder{
+		db:      db,
+		seen:    make(map[postViewer]time.Time),
+		pending: make(map[postDay]uint64),
+	}
+}
+
+// count a view of a post, unless the viewer already viewed it within the dedup window.
+// viewer identifies who's reading, such as a user ID or an IP address for anonymous readers
+func (vr *ViewRecorder) Record(postId uint64, viewer string) {
+	now := time.Now()
+	key := postViewer{postId: postId, viewer: viewer}
+
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+
+	if last, ok := vr.seen[key]; ok && now.Sub(last) < httpcommon.ViewConstants.DedupWindow {
+		return
+	}
+	vr.seen[key] = now
+	vr.pending[postDay{postId: postId, day: now.UTC().Format(time.DateOnly)}]++
+}
+
+// flush counted views on every tick, and once more when the context is cancelled
+// so views counted right before a shutdown aren't lost
+func (vr *ViewRecorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(httpcommon.ViewConstants.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := vr.Flush(); err != nil {
+				helpers.MessageLogs.ErrorLog.Println(err)
+			}
+			return
+		case <-ticker.C:
+			if err := vr.Flush(); err != nil {
+				helpers.MessageLogs.ErrorLog.Println(err)
+			}
+		}
+	}
+}
+
+// write the views counted since the last flush, they're kept for the next one if that fails
+func (vr *ViewRecorder) Flush() error {
+	vr.mu.Lock()
+	pending := vr.pending
+	vr.pending = make(map[postDay]uint64)
+	// viewers outside the window would be counted again anyway, no need to remember them
+	now := time.Now()
+	for key, last := range vr.seen {
+		if now.Sub(last) >= httpcommon.ViewConstants.DedupWindow {
+			delete(vr.seen, key)
+		}
+	}
+	vr.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := vr.write(pending); err != nil {
+		vr.mu.Lock()
+		for key, views := range pending {
+			vr.pending[key] += views
+		}
+		vr.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (vr *ViewRecorder) write(pending map[postDay]uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpcommon.DbConstants.Timeout)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	totals := make(map[uint64]uint64)
+	for key, views := range pending {
+		// selecting from posts skips the ones deleted since they were viewed
+		query := `
+			INSERT INTO post_view_days (post_id, day, views)
+			SELECT id, ?, ? FROM posts WHERE id = ?
+			ON DUPLICATE KEY UPDATE views = views + VALUES(views)
+		`
+		if _, err = tx.ExecContext(ctx, query, key.day, views, key.postId); err != nil {
+			return err
+		}
+		totals[key.postId] += views
+	}
+
+	// being viewed isn't an edit, so updated_at is kept as it is
+	for postId, views := range totals {
+		query := "UPDATE posts SET view_count = view_count + ?, updated_at = updated_at WHERE id = ?"
+		if _, err = tx.ExecContext(ctx, query, views, postId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
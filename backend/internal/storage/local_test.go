@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"media/1/photo.jpg", true},
+		{"photo.jpg", true},
+		{"", false},
+		{"../photo.jpg", false},
+		{"media/../../photo.jpg", false},
+		{"/etc/passwd", false},
+		{"media//photo.jpg", false},
+		{"media/./photo.jpg", false},
+		{"media/1/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := storage.Put(context.Background(), tt.key, []byte("data"), "image/png")
+			if tt.valid && err != nil {
+				t.Errorf("Put(%q) = %v, want no error", tt.key, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Put(%q) succeeded, want an error", tt.key)
+			}
+		})
+	}
+}
+
+func TestLocalStoragePutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewLocalStorage(dir, "http://localhost:8080/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	key := "media/1/photo.jpg"
+	if err = storage.Put(ctx, key, []byte("jpeg bytes"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "media", "1", "photo.jpg"))
+	if err != nil || string(data) != "jpeg bytes" {
+		t.Fatalf("read back %q, %v", data, err)
+	}
+	if got, want := storage.URL(key), "http://localhost:8080/media/media/1/photo.jpg"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	if err = storage.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "media", "1", "photo.jpg")); !os.IsNotExist(err) {
+		t.Errorf("file still there after Delete: %v", err)
+	}
+	// deleting what's already gone isn't an error
+	if err = storage.Delete(ctx, key); err != nil {
+		t.Errorf("second Delete = %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// a minimal stand-in for MinIO: path-style PUT and DELETE on objects, rejecting requests
+// whose SigV4 signature doesn't match the one it computes itself
+type fakeS3 struct {
+	accessKey string
+	secretKey string
+	region    string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !f.validSignature(r, body) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path] = fakeObject{data: body, contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) validSignature(r *http.Request, body []byte) bool {
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	if r.Header.Get("X-Amz-Content-Sha256") != payloadHash {
+		return false
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if len(amzDate) != len("20060102T150405Z") {
+		return false
+	}
+	scope := amzDate[:8] + "/" + f.region + "/s3/aws4_request"
+	canonicalRequest := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		payloadHash
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + f.secretKey)
+	for _, part := range []string{amzDate[:8], f.region, "s3", "aws4_request"} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+
+	want := "AWS4-HMAC-SHA256 Credential=" + f.accessKey + "/" + scope +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(mac.Sum(nil))
+	return r.Header.Get("Authorization") == want
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{accessKey: "minio", secretKey: "minio-secret", region: "us-east-1", objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func TestS3StoragePutAndDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	storage, err := NewS3Storage(S3Config{
+		Endpoint:  server.URL + "/",
+		Region:    fake.region,
+		Bucket:    "uploads",
+		AccessKey: fake.accessKey,
+		SecretKey: fake.secretKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	key := "media/1/photo one.jpg"
+	if err = storage.Put(ctx, key, []byte("jpeg bytes"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	object, ok := fake.objects["/uploads/media/1/photo one.jpg"]
+	if !ok {
+		t.Fatalf("object not stored, have %v", fake.objects)
+	}
+	if string(object.data) != "jpeg bytes" || object.contentType != "image/jpeg" {
+		t.Errorf("stored %q as %q", object.data, object.contentType)
+	}
+	if got, want := storage.URL(key), server.URL+"/uploads/media/1/photo%20one.jpg"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	if err = storage.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if len(fake.objects) != 0 {
+		t.Errorf("object still stored after Delete: %v", fake.objects)
+	}
+}
+
+func TestS3StorageRejectedSignature(t *testing.T) {
+	fake, server := newFakeS3(t)
+	storage, err := NewS3Storage(S3Config{
+		Endpoint:  server.URL,
+		Region:    fake.region,
+		Bucket:    "uploads",
+		AccessKey: fake.accessKey,
+		SecretKey: "wrong-secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Put(context.Background(), "media/1/a.png", []byte("png"), "image/png")
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Fatalf("Put with a bad secret = %v, want a 403 error", err)
+	}
+	if len(fake.objects) != 0 {
+		t.Errorf("object stored despite the bad signature")
+	}
+}
+
+func TestNewS3StorageRequiresConfig(t *testing.T) {
+	if _, err := NewS3Storage(S3Config{Endpoint: "http://localhost:9000", Bucket: "uploads"}); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
@@ -0,0 +1,131 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// the media type other servers send and expect, application/ld+json with the
+	// ActivityStreams profile is accepted as an equivalent
+	ContentType = "application/activity+json"
+	// addressing an activity to this collection makes it public
+	PublicCollection = "https://www.w3.org/ns/activitystreams#Public"
+
+	activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	securityContext        = "https://w3id.org/security/v1"
+)
+
+// the @context of actor documents, which also describe their public key
+var ActorContext = []string{activityStreamsContext, securityContext}
+
+// the @context of every other top level object
+var ObjectContext = activityStreamsContext
+
+type Actor struct {
+	Context           interface{} `json:"@context,omitempty"`
+	ID                string      `json:"id"`
+	Type              string      `json:"type"`
+	PreferredUsername string      `json:"preferredUsername"`
+	Name              string      `json:"name,omitempty"`
+	URL               string      `json:"url,omitempty"`
+	Inbox             string      `json:"inbox"`
+	Outbox            string      `json:"outbox,omitempty"`
+	Followers         string      `json:"followers,omitempty"`
+	Endpoints         *Endpoints  `json:"endpoints,omitempty"`
+	PublicKey         PublicKey   `json:"publicKey"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Note struct {
+	Context      interface{} `json:"@context,omitempty"`
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	AttributedTo string      `json:"attributedTo"`
+	Content      string      `json:"content"`
+	URL          string      `json:"url,omitempty"`
+	Published    *time.Time  `json:"published,omitempty"`
+	Updated      *time.Time  `json:"updated,omitempty"`
+	To           []string    `json:"to,omitempty"`
+	Cc           []string    `json:"cc,omitempty"`
+}
+
+// an activity we send, the object being a nested object or the ID of one
+type Activity struct {
+	Context interface{} `json:"@context,omitempty"`
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+	Actor   string      `json:"actor"`
+	Object  interface{} `json:"object"`
+	To      []string    `json:"to,omitempty"`
+	Cc      []string    `json:"cc,omitempty"`
+}
+
+// an activity we receive, the object is decoded depending on the type of activity
+type IncomingActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  json.RawMessage `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+type OrderedCollection struct {
+	Context      interface{}   `json:"@context,omitempty"`
+	ID           string        `json:"id"`
+	Type         string        `json:"type"`
+	TotalItems   uint64        `json:"totalItems"`
+	First        string        `json:"first,omitempty"`
+	PartOf       string        `json:"partOf,omitempty"`
+	Next         string        `json:"next,omitempty"`
+	OrderedItems []interface{} `json:"orderedItems,omitempty"`
+}
+
+// the JSON resource descriptor returned by WebFinger
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href,omitempty"`
+}
+
+// a property that may hold either an object or just its ID
+type reference struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// helper function to read the ID of a property holding either an object or the ID itself
+func RefID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	var ref reference
+	if err := json.Unmarshal(raw, &ref); err == nil {
+		return ref.ID
+	}
+	return ""
+}
+
+// helper function to read the type of a nested object, empty when only its ID was given
+func RefType(raw json.RawMessage) string {
+	var ref reference
+	if err := json.Unmarshal(raw, &ref); err == nil {
+		return ref.Type
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/netguard"
+)
+
+const (
+	// remote documents are small, anything bigger than this isn't something we want
+	maxResponseBytes = 1 << 20
+	maxRedirects     = 3
+)
+
+var ErrInvalidURL = errors.New("remote URL must be absolute and use https")
+
+// remote servers pick the URLs we fetch, including the key IDs of unverified requests,
+// so requests only go out to public addresses
+var client = netguard.NewClient(
+	httpcommon.FederationConstants.RequestTimeout,
+	maxRedirects,
+	httpcommon.FederationConstants.AllowPrivate,
+	CheckURL,
+)
+
+// returned for responses outside the 2xx range
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.URL, e.StatusCode)
+}
+
+// helper function to make sure a URL handed to us by another server is one we may request
+func CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return ErrInvalidURL
+	}
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && httpcommon.FederationConstants.AllowHTTP) {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// fetch a remote object, such as an actor document, and decode it into out
+func Fetch(ctx context.Context, uri string, out interface{}) error {
+	if err := CheckURL(uri); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", ContentType)
+
+	return doJSON(req, out)
+}
+
+// post a signed activity to a remote inbox
+func Deliver(ctx context.Context, inbox string, activity []byte, keyId string, key *rsa.PrivateKey) error {
+	if err := CheckURL(inbox); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if err = Sign(req, activity, keyId, key); err != nil {
+		return err
+	}
+
+	return doJSON(req, nil)
+}
+
+// resolve a user@domain handle to the ID of the actor behind it
+func LookupWebFinger(ctx context.Context, handle string) (string, error) {
+	username, domain, ok := strings.Cut(strings.TrimPrefix(handle, "@"), "@")
+	if !ok || username == "" || domain == "" || strings.ContainsAny(domain, "/?#@") {
+		return "", ErrInvalidURL
+	}
+
+	scheme := "https"
+	if httpcommon.FederationConstants.AllowHTTP {
+		scheme = "http"
+	}
+	query := url.Values{"resource": {"acct:" + username + "@" + domain}}
+	endpoint := fmt.Sprintf("%s://%s/.well-known/webfinger?%s", scheme, domain, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/jrd+json")
+
+	var resource WebFinger
+	if err = doJSON(req, &resource); err != nil {
+		return "", err
+	}
+	for _, link := range resource.Links {
+		if link.Rel == "self" && IsActivityContentType(link.Type) && link.Href != "" {
+			return link.Href, nil
+		}
+	}
+
+	return "", &StatusError{URL: endpoint, StatusCode: http.StatusNotFound}
+}
+
+// helper function to tell if a media type is one ActivityPub objects are served as
+func IsActivityContentType(contentType string) bool {
+	mediaType, params, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == ContentType ||
+		(mediaType == "application/ld+json" && strings.Contains(params, "https://www.w3.org/ns/activitystreams"))
+}
+
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// drain what's left so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+		return &StatusError{URL: req.URL.String(), StatusCode: resp.StatusCode}
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out)
+}
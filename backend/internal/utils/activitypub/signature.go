@@ -0,0 +1,173 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HTTP signatures as described by draft-cavage-http-signatures, which is what
+// the fediverse settled on: rsa-sha256 over the request target, host, date and body digest
+
+var (
+	ErrMissingSignature   = errors.New("request is not signed")
+	ErrMalformedSignature = errors.New("signature header is malformed")
+	ErrUnsignedHeaders    = errors.New("signature does not cover the required headers")
+	ErrExpiredSignature   = errors.New("signature date is too far from the current time")
+	ErrDigestMismatch     = errors.New("digest does not match the request body")
+	ErrBadSignature       = errors.New("signature verification failed")
+)
+
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+}
+
+// helper function to build the Digest header of a request body
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// sign a request with an actor's key, setting the Date and Digest headers it covers.
+// body has to be the exact bytes sent as the request body
+func Sign(req *http.Request, body []byte, keyId string, key *rsa.PrivateKey) error {
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+
+	headers := []string{"(request-target)", "host", "date"}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		req.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyId,
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// read the Signature header of a request, without checking it yet
+// since the key it names usually has to be looked up first
+func ParseSignature(req *http.Request) (*Signature, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		// some implementations use the Authorization header instead
+		header, _ = strings.CutPrefix(req.Header.Get("Authorization"), "Signature ")
+	}
+	if header == "" {
+		return nil, ErrMissingSignature
+	}
+
+	params := make(map[string]string)
+	for header != "" {
+		name, rest, ok := strings.Cut(header, `="`)
+		if !ok {
+			return nil, ErrMalformedSignature
+		}
+		value, rest, ok := strings.Cut(rest, `"`)
+		if !ok {
+			return nil, ErrMalformedSignature
+		}
+		params[strings.ToLower(strings.TrimSpace(name))] = value
+		header = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil || params["keyid"] == "" {
+		return nil, ErrMalformedSignature
+	}
+
+	// only the Date header is covered when the list is left out
+	headers := []string{"date"}
+	if params["headers"] != "" {
+		headers = strings.Fields(strings.ToLower(params["headers"]))
+	}
+
+	return &Signature{
+		KeyID:     params["keyid"],
+		Algorithm: params["algorithm"],
+		Headers:   headers,
+		Signature: signature,
+	}, nil
+}
+
+// check a parsed signature against the sender's public key, along with the freshness
+// of the Date header and, for requests with a body, the Digest header
+func Verify(req *http.Request, body []byte, signature *Signature, key *rsa.PublicKey, maxAge time.Duration) error {
+	// "hs2019" leaves the algorithm to the key, which for us is always RSA
+	if signature.Algorithm != "" && signature.Algorithm != "rsa-sha256" && signature.Algorithm != "hs2019" {
+		return ErrMalformedSignature
+	}
+
+	required := []string{"(request-target)", "host", "date"}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		required = append(required, "digest")
+	}
+	for _, header := range required {
+		if !slices.Contains(signature.Headers, header) {
+			return ErrUnsignedHeaders
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return ErrExpiredSignature
+	}
+	if age := time.Since(date); age > maxAge || age < -maxAge {
+		return ErrExpiredSignature
+	}
+
+	if slices.Contains(signature.Headers, "digest") {
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("Digest")), []byte(Digest(body))) != 1 {
+			return ErrDigestMismatch
+		}
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, signature.Headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature.Signature); err != nil {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		var value string
+		switch header {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+		default:
+			value = strings.Join(req.Header.Values(header), ", ")
+		}
+		lines[i] = header + ": " + value
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,195 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKeyId = "https://remote.example/ap/users/alice#main-key"
+
+func testKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	_, privatePem, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePrivateKey(privatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// send a request to a local server standing in for the receiving instance and
+// verify it there, the way the inbox sees it after it went over the wire
+func verifyOverWire(t *testing.T, req *http.Request, body []byte, key *rsa.PublicKey) error {
+	t.Helper()
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, err := io.ReadAll(r.Body)
+		if err != nil {
+			verifyErr = err
+			return
+		}
+		signature, err := ParseSignature(r)
+		if err != nil {
+			verifyErr = err
+			return
+		}
+		if signature.KeyID != testKeyId {
+			verifyErr = fmt.Errorf("keyId = %q", signature.KeyID)
+			return
+		}
+		verifyErr = Verify(r, received, signature, key, time.Hour)
+	}))
+	defer server.Close()
+
+	// aim the signed request at the stand-in while keeping the Host it was signed for
+	target := *req.URL
+	target.Scheme, target.Host = "http", strings.TrimPrefix(server.URL, "http://")
+	req.URL = &target
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	return verifyErr
+}
+
+func newSignedRequest(t *testing.T, key *rsa.PrivateKey, body []byte, date time.Time) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "https://local.example/ap/users/bob/inbox", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+	if err = Sign(req, body, testKeyId, key); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	key := testKey(t)
+	body := []byte(`{"type":"Follow"}`)
+
+	req := newSignedRequest(t, key, body, time.Now())
+	if err := verifyOverWire(t, req, body, &key.PublicKey); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	key := testKey(t)
+	otherKey := testKey(t)
+	body := []byte(`{"type":"Create"}`)
+
+	tests := []struct {
+		name    string
+		request func() (*http.Request, []byte)
+		key     *rsa.PublicKey
+		want    error
+	}{
+		{
+			name: "expired date",
+			request: func() (*http.Request, []byte) {
+				return newSignedRequest(t, key, body, time.Now().Add(-2*time.Hour)), body
+			},
+			key:  &key.PublicKey,
+			want: ErrExpiredSignature,
+		},
+		{
+			name: "date in the future",
+			request: func() (*http.Request, []byte) {
+				return newSignedRequest(t, key, body, time.Now().Add(2*time.Hour)), body
+			},
+			key:  &key.PublicKey,
+			want: ErrExpiredSignature,
+		},
+		{
+			name: "body changed after signing",
+			request: func() (*http.Request, []byte) {
+				return newSignedRequest(t, key, body, time.Now()), []byte(`{"type":"Delete"}`)
+			},
+			key:  &key.PublicKey,
+			want: ErrDigestMismatch,
+		},
+		{
+			name: "signed by another key",
+			request: func() (*http.Request, []byte) {
+				return newSignedRequest(t, otherKey, body, time.Now()), body
+			},
+			key:  &key.PublicKey,
+			want: ErrBadSignature,
+		},
+		{
+			name: "missing request target",
+			request: func() (*http.Request, []byte) {
+				req := newSignedRequest(t, key, body, time.Now())
+				// re-sign over everything but the request target, so only the header list is wrong
+				headers := []string{"host", "date", "digest"}
+				hashed := sha256.Sum256([]byte(signingString(req, headers)))
+				signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Signature", fmt.Sprintf(
+					`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+					testKeyId, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature),
+				))
+				return req, body
+			},
+			key:  &key.PublicKey,
+			want: ErrUnsignedHeaders,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, sent := tt.request()
+			if err := verifyOverWire(t, req, sent, tt.key); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   error
+	}{
+		{"missing", "", ErrMissingSignature},
+		{"unterminated value", `keyId="a,signature="b`, ErrMalformedSignature},
+		{"no key id", `algorithm="rsa-sha256",signature="c2ln"`, ErrMalformedSignature},
+		{"bad base64", `keyId="a",signature="%%%"`, ErrMalformedSignature},
+		{"valid", `keyId="a",headers="(request-target) host date",signature="c2ln"`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/ap/inbox", nil)
+			if tt.header != "" {
+				req.Header.Set("Signature", tt.header)
+			}
+			if _, err := ParseSignature(req); !errors.Is(err, tt.want) {
+				t.Errorf("ParseSignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 64
+
+// extracts the distinct hashtags in a piece of text, lowercased and without the leading '#'
+// a hashtag has to start the text or follow a non-word character, and contain at least one letter
+func Hashtags(text string) []string {
+	runes := []rune(text)
+	seen := map[string]bool{}
+	tags := []string{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isWordRune(runes[end]) {
+			if unicode.IsLetter(runes[end]) {
+				hasLetter = true
+			}
+			end++
+		}
+
+		tag := strings.ToLower(string(runes[i+1 : end]))
+		if hasLetter && len([]rune(tag)) <= maxHashtagLength && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		i = end - 1
+	}
+
+	return tags
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+type Mention struct {
+	Username string
+	// offsets are in Unicode code points and cover the leading '@', End is exclusive
+	Start int
+	End   int
+}
+
+// extracts every @username token in a piece of text, in order of appearance
+// a mention has to start the text or follow a non-word character, so email addresses are skipped
+func Mentions(text string) []Mention {
+	runes := []rune(text)
+	mentions := []Mention{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '.')) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isUsernameRune(runes[end]) {
+			end++
+		}
+		// punctuation ending a sentence isn't part of the username
+		for end > i+1 && (runes[end-1] == '.' || runes[end-1] == '-') {
+			end--
+		}
+
+		if end > i+1 {
+			mentions = append(mentions, Mention{Username: string(runes[i+1 : end]), Start: i, End: end})
+		}
+		i = end - 1
+	}
+
+	return mentions
+}
+
+func isUsernameRune(r rune) bool {
+	return isWordRune(r) || r == '.' || r == '-'
+}
+
+// extracts the http(s) URLs in a piece of text, in order of appearance, whether they're
+// bare or the target of a Markdown link, trailing sentence punctuation isn't part of a URL
+func Links(text string) []string {
+	runes := []rune(text)
+	links := []string{}
+
+	for i := 0; i < len(runes); i++ {
+		if !hasPrefixAt(runes, i, "http://") && !hasPrefixAt(runes, i, "https://") {
+			continue
+		}
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		for end > i && strings.ContainsRune(".,;:!?)'\"", runes[end-1]) {
+			end--
+		}
+
+		links = append(links, string(runes[i:end]))
+		i = end - 1
+	}
+
+	return links
+}
+
+func hasPrefixAt(runes []rune, i int, prefix string) bool {
+	prefixRunes := []rune(prefix)
+	if i+len(prefixRunes) > len(runes) {
+		return false
+	}
+	for j, r := range prefixRunes {
+		if unicode.ToLower(runes[i+j]) != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/domain/models"
+)
+
+// a row read back from an import file, Err is set when the row itself couldn't be parsed
+// so a single bad row doesn't fail the whole file
+type DecodedRow struct {
+	Post models.ImportedPost
+	Err  error
+}
+
+// read an import file in one of the formats the export writes, other than Markdown
+func Decode(r io.Reader, format string, maxRows int) ([]DecodedRow, error) {
+	switch format {
+	case httpcommon.ExportFormat.JSON:
+		return DecodeJSON(r, maxRows)
+	case httpcommon.ExportFormat.CSV:
+		return DecodeCSV(r, maxRows)
+	default:
+		return nil, errors.New(httpcommon.ErrorMessage.InvalidImportFormat)
+	}
+}
+
+// read a JSON array of posts, at most maxRows of them
+func DecodeJSON(r io.Reader, maxRows int) ([]DecodedRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if len(raw) > maxRows {
+		return nil, errors.New(httpcommon.ErrorMessage.ImportTooLarge)
+	}
+
+	rows := make([]DecodedRow, len(raw))
+	for i, message := range raw {
+		rows[i].Err = json.Unmarshal(message, &rows[i].Post)
+	}
+
+	return rows, nil
+}
+
+// read a CSV file with a header row, at most maxRows of them not counting the header.
+// columns are matched by name and unknown ones are ignored, timestamps are RFC 3339
+func DecodeCSV(r io.Reader, maxRows int) ([]DecodedRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []DecodedRow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["content"]; !ok {
+		return nil, errors.New("CSV header has no content column")
+	}
+
+	rows := []DecodedRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows) == maxRows {
+			return nil, errors.New(httpcommon.ErrorMessage.ImportTooLarge)
+		}
+		if err != nil {
+			// csv.ParseError keeps the reader usable, so carry on with the next line
+			rows = append(rows, DecodedRow{Err: err})
+			continue
+		}
+
+		rows = append(rows, decodeCSVRecord(record, columns))
+	}
+
+	return rows, nil
+}
+
+// undo escapeCSVCell, a quote in front of anything it wouldn't have escaped is kept
+// so hand-written files starting a post with a quote read as they were meant to
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes+"'", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func decodeCSVRecord(record []string, columns map[string]int) DecodedRow {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	row := DecodedRow{Post: models.ImportedPost{
+		ImportKey:  field("import_key"),
+		Content:    unescapeCSVCell(field("content")),
+		Status:     field("status"),
+		Visibility: field("visibility"),
+	}}
+
+	for name, target := range map[string]**time.Time{
+		"publish_at": &row.Post.PublishAt,
+		"created_at": &row.Post.CreatedAt,
+		"updated_at": &row.Post.UpdatedAt,
+	} {
+		value := field(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			row.Err = fmt.Errorf("%s: %w", name, err)
+			return row
+		}
+		*target = &parsed
+	}
+
+	return row
+}
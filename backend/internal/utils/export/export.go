@@ -0,0 +1,188 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"chi-mysql-boilerplate/internal/domain/models"
+)
+
+// writes posts one at a time in some file format, Close finishes the file
+// but leaves the underlying writer open
+type Encoder interface {
+	Write(post models.ExportedPost) error
+	Close() error
+}
+
+// a JSON array of posts
+type JSONEncoder struct {
+	w       io.Writer
+	written bool
+}
+
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: w}
+}
+
+func (e *JSONEncoder) Write(post models.ExportedPost) error {
+	out, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if !e.written {
+		separator = "[\n"
+		e.written = true
+	}
+	_, err = io.WriteString(e.w, separator+string(out))
+	return err
+}
+
+func (e *JSONEncoder) Close() error {
+	if !e.written {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+var csvHeader = []string{
+	"id", "user_id", "username", "content", "status", "visibility", "publish_at",
+	"repost_of_id", "quote_of_id", "tags", "created_at", "updated_at",
+}
+
+// a CSV file with a header row, tags are separated by spaces
+type CSVEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func NewCSVEncoder(w io.Writer) *CSVEncoder {
+	return &CSVEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *CSVEncoder) Write(post models.ExportedPost) error {
+	if !e.headerWritten {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	e.w.Write([]string{
+		strconv.FormatUint(post.ID, 10),
+		strconv.FormatUint(post.UserID, 10),
+		escapeCSVCell(post.UserName),
+		escapeCSVCell(post.Content),
+		post.Status,
+		post.Visibility,
+		formatOptionalTime(post.PublishAt),
+		formatOptionalId(post.RepostOfID),
+		formatOptionalId(post.QuoteOfID),
+		strings.Join(post.Tags, " "),
+		post.CreatedAt.UTC().Format(time.RFC3339),
+		post.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+	// hand each row to the underlying writer right away rather than buffering the export
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// spreadsheet apps run cells starting with these as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// helper function to keep user-written text from being run as a formula when the file is opened
+// in a spreadsheet app, by putting a quote in front of it. cells already starting with a quote get
+// one too, so unescapeCSVCell can tell the two apart
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes+"'", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *CSVEncoder) Close() error {
+	if !e.headerWritten {
+		e.w.Write(csvHeader)
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// a zip archive with one Markdown file per post, its metadata kept in YAML front matter
+type MarkdownZipEncoder struct {
+	w *zip.Writer
+}
+
+func NewMarkdownZipEncoder(w io.Writer) *MarkdownZipEncoder {
+	return &MarkdownZipEncoder{w: zip.NewWriter(w)}
+}
+
+func (e *MarkdownZipEncoder) Write(post models.ExportedPost) error {
+	file, err := e.w.CreateHeader(&zip.FileHeader{
+		Name:     fmt.Sprintf("posts/%d.md", post.ID),
+		Method:   zip.Deflate,
+		Modified: post.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// quoted strings are JSON-escaped, which YAML reads as double-quoted scalars
+	var out strings.Builder
+	out.WriteString("---\n")
+	fmt.Fprintf(&out, "id: %d\n", post.ID)
+	fmt.Fprintf(&out, "user_id: %d\n", post.UserID)
+	fmt.Fprintf(&out, "username: %s\n", strconv.Quote(post.UserName))
+	fmt.Fprintf(&out, "status: %s\n", post.Status)
+	fmt.Fprintf(&out, "visibility: %s\n", post.Visibility)
+	fmt.Fprintf(&out, "publish_at: %s\n", orNull(formatOptionalTime(post.PublishAt)))
+	fmt.Fprintf(&out, "repost_of_id: %s\n", orNull(formatOptionalId(post.RepostOfID)))
+	fmt.Fprintf(&out, "quote_of_id: %s\n", orNull(formatOptionalId(post.QuoteOfID)))
+	quotedTags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		quotedTags[i] = strconv.Quote(tag)
+	}
+	fmt.Fprintf(&out, "tags: [%s]\n", strings.Join(quotedTags, ", "))
+	fmt.Fprintf(&out, "created_at: %s\n", post.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&out, "updated_at: %s\n", post.UpdatedAt.UTC().Format(time.RFC3339))
+	out.WriteString("---\n\n")
+	out.WriteString(post.Content)
+	out.WriteString("\n")
+
+	_, err = io.WriteString(file, out.String())
+	return err
+}
+
+func (e *MarkdownZipEncoder) Close() error {
+	return e.w.Close()
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatOptionalId(id *uint64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(*id, 10)
+}
+
+func orNull(value string) string {
+	if value == "" {
+		return "null"
+	}
+	return value
+}
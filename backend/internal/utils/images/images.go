@@ -0,0 +1,254 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var ErrUnsupportedType = errors.New("unsupported image type")
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// the header describes an image but the data after it doesn't decode, such as a truncated upload
+var ErrCorruptImage = errors.New("image data is corrupt")
+
+type Encoded struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+type Processed struct {
+	Image     Encoded
+	Thumbnail Encoded
+}
+
+// sniff, validate and re-encode an uploaded image, which drops any EXIF or other metadata it carried
+// JPEG orientation is applied to the pixels first so photos don't end up sideways
+func Process(data []byte, maxPixels int, thumbnailSize int) (*Processed, error) {
+	contentType := http.DetectContentType(data)
+
+	// check the dimensions before decoding so a tiny file can't expand into gigabytes of pixels
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var processed Processed
+	var frame image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptImage, err)
+		}
+		frame = applyOrientation(img, jpegOrientation(data))
+
+		if processed.Image, err = encodeJPEG(frame); err != nil {
+			return nil, err
+		}
+		if processed.Thumbnail, err = encodeJPEG(resize(frame, thumbnailSize)); err != nil {
+			return nil, err
+		}
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptImage, err)
+		}
+		frame = img
+
+		if processed.Image, err = encodePNG(frame); err != nil {
+			return nil, err
+		}
+		if processed.Thumbnail, err = encodePNG(resize(frame, thumbnailSize)); err != nil {
+			return nil, err
+		}
+	case "image/gif":
+		// every frame is decoded into memory, so their sizes are added up before any of them is
+		if err := checkGIFPixels(data, maxPixels); err != nil {
+			return nil, err
+		}
+		// keep every frame so animations survive, comments and application extensions are dropped
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptImage, err)
+		}
+		var out bytes.Buffer
+		if err = gif.EncodeAll(&out, &gif.GIF{
+			Image:     animation.Image,
+			Delay:     animation.Delay,
+			LoopCount: animation.LoopCount,
+			Disposal:  animation.Disposal,
+			Config:    animation.Config,
+		}); err != nil {
+			return nil, err
+		}
+		processed.Image = Encoded{
+			Data:        out.Bytes(),
+			ContentType: contentType,
+			Extension:   ".gif",
+			Width:       animation.Config.Width,
+			Height:      animation.Config.Height,
+		}
+
+		// the thumbnail is a still of the first frame
+		if processed.Thumbnail, err = encodePNG(resize(animation.Image[0], thumbnailSize)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	return &processed, nil
+}
+
+// walk the blocks of a GIF without decompressing anything and add up the size of each frame
+// as its image descriptor states it, returns ErrTooLarge once they go over maxPixels
+func checkGIFPixels(data []byte, maxPixels int) error {
+	// header and logical screen descriptor
+	const headerSize = 13
+	if len(data) < headerSize {
+		return ErrUnsupportedType
+	}
+	pos := headerSize
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+
+	// skip a run of data sub-blocks, each prefixed with its size and ended by an empty one
+	skipSubBlocks := func() bool {
+		for pos < len(data) {
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	pixels := 0
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension, the label is followed by sub-blocks
+			pos += 2
+			if !skipSubBlocks() {
+				return ErrUnsupportedType
+			}
+		case 0x2c: // image descriptor
+			if pos+10 > len(data) {
+				return ErrUnsupportedType
+			}
+			width := int(data[pos+5]) | int(data[pos+6])<<8
+			height := int(data[pos+7]) | int(data[pos+8])<<8
+			pixels += width * height
+			if pixels > maxPixels {
+				return ErrTooLarge
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			// the LZW minimum code size comes before the image data
+			pos++
+			if !skipSubBlocks() {
+				return ErrUnsupportedType
+			}
+		case 0x3b: // trailer
+			return nil
+		default:
+			return ErrUnsupportedType
+		}
+	}
+
+	// a missing trailer is left for the decoder to deal with
+	return nil
+}
+
+func encodeJPEG(img image.Image) (Encoded, error) {
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: 85}); err != nil {
+		return Encoded{}, err
+	}
+
+	bounds := img.Bounds()
+	return Encoded{Data: out.Bytes(), ContentType: "image/jpeg", Extension: ".jpg", Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+func encodePNG(img image.Image) (Encoded, error) {
+	var out bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&out, img); err != nil {
+		return Encoded{}, err
+	}
+
+	bounds := img.Bounds()
+	return Encoded{Data: out.Bytes(), ContentType: "image/png", Extension: ".png", Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+// scale an image down to fit in a size x size box by averaging the source pixels under each
+// destination pixel, images that already fit are returned as they are
+func resize(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth <= size && srcHeight <= size {
+		return img
+	}
+
+	dstWidth, dstHeight := size, size
+	if srcWidth > srcHeight {
+		dstHeight = max(1, srcHeight*size/srcWidth)
+	} else {
+		dstWidth = max(1, srcWidth*size/srcHeight)
+	}
+
+	src := toRGBA(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*srcHeight/dstHeight, max((y+1)*srcHeight/dstHeight, y*srcHeight/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*srcWidth/dstWidth, max((x+1)*srcWidth/dstWidth, x*srcWidth/dstWidth+1)
+
+			var r, g, b, a, count int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					r += int(pixel[0])
+					g += int(pixel[1])
+					b += int(pixel[2])
+					a += int(pixel[3])
+					count++
+				}
+			}
+
+			offset := y*dst.Stride + x*4
+			dst.Pix[offset] = uint8(r / count)
+			dst.Pix[offset+1] = uint8(g / count)
+			dst.Pix[offset+2] = uint8(b / count)
+			dst.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return dst
+}
+
+// copy an image into a zero-based RGBA buffer with premultiplied alpha
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
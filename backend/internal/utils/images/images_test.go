@@ -0,0 +1,121 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width int, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodeTestJPEG(t *testing.T, width int, height int) []byte {
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, testImage(width, height), nil); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func encodeTestPNG(t *testing.T, width int, height int) []byte {
+	var out bytes.Buffer
+	if err := png.Encode(&out, testImage(width, height)); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func encodeTestGIF(t *testing.T, frames int, width int, height int) []byte {
+	var animation gif.GIF
+	for i := 0; i < frames; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9))
+		animation.Delay = append(animation.Delay, 10)
+	}
+	var out bytes.Buffer
+	if err := gif.EncodeAll(&out, &animation); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	jpegData := encodeTestJPEG(t, 64, 48)
+	pngData := encodeTestPNG(t, 64, 48)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		wantErr   error
+		wantType  string
+	}{
+		{"jpeg", jpegData, 10000, nil, "image/jpeg"},
+		{"png", pngData, 10000, nil, "image/png"},
+		{"animated gif", encodeTestGIF(t, 3, 40, 40), 10000, nil, "image/gif"},
+		{"jpeg over the pixel limit", jpegData, 1000, ErrTooLarge, ""},
+		{"gif frames over the pixel limit", encodeTestGIF(t, 10, 40, 40), 10000, ErrTooLarge, ""},
+		// cut into the scan data, past the headers DecodeConfig reads
+		{"truncated jpeg", jpegData[:len(jpegData)*4/5], 10000, ErrCorruptImage, ""},
+		{"truncated png", pngData[:len(pngData)/2], 10000, ErrCorruptImage, ""},
+		{"not an image", []byte("just some text, not an image at all"), 10000, ErrUnsupportedType, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := Process(tt.data, tt.maxPixels, 16)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if processed.Image.ContentType != tt.wantType {
+				t.Errorf("content type = %q, want %q", processed.Image.ContentType, tt.wantType)
+			}
+			if processed.Thumbnail.Width > 16 || processed.Thumbnail.Height > 16 {
+				t.Errorf("thumbnail is %dx%d, want it to fit in 16x16", processed.Thumbnail.Width, processed.Thumbnail.Height)
+			}
+		})
+	}
+}
+
+func TestCheckGIFPixels(t *testing.T) {
+	threeFrames := encodeTestGIF(t, 3, 100, 100)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		want      error
+	}{
+		{"within the limit", threeFrames, 30000, nil},
+		{"frames add up past the limit", threeFrames, 29999, ErrTooLarge},
+		{"single frame over the limit", encodeTestGIF(t, 1, 200, 200), 30000, ErrTooLarge},
+		{"too short for a header", []byte("GIF89a"), 30000, ErrUnsupportedType},
+		{"truncated image data", threeFrames[:len(threeFrames)/2], 30000, ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkGIFPixels(tt.data, tt.maxPixels); !errors.Is(err, tt.want) {
+				t.Errorf("checkGIFPixels() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
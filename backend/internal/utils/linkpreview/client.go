@@ -0,0 +1,96 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/netguard"
+)
+
+const maxRedirects = 3
+
+var (
+	ErrInvalidURL = errors.New("link must be an absolute http or https URL")
+	// the link, or a redirect it led to, points at an address we don't make requests to
+	ErrBlockedAddress = netguard.ErrBlockedAddress
+	ErrNotHTML        = errors.New("link is not an HTML page")
+)
+
+// returned for responses outside the 2xx range
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.URL, e.StatusCode)
+}
+
+var client = netguard.NewClient(
+	httpcommon.LinkPreviewConstants.RequestTimeout,
+	maxRedirects,
+	httpcommon.LinkPreviewConstants.AllowPrivate,
+	CheckURL,
+)
+
+// helper function to make sure a link is something we'd fetch at all,
+// where it actually leads is only known once it's resolved
+func CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return ErrInvalidURL
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// fetch a page and read the preview out of its metadata
+func Fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	if err := CheckURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "LinkPreviewBot/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{URL: rawURL, StatusCode: resp.StatusCode}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	// redirects may have moved us, relative image URLs are relative to where we ended up
+	return parse(io.LimitReader(resp.Body, httpcommon.LinkPreviewConstants.MaxBodyBytes), resp.Request.URL)
+}
+
+// helper function to tell failures that may go away on their own from the ones that won't
+func Retryable(err error) bool {
+	if errors.Is(err, ErrInvalidURL) || errors.Is(err, ErrBlockedAddress) || errors.Is(err, ErrNotHTML) || errors.Is(err, ErrNoMetadata) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
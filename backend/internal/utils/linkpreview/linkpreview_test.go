@@ -0,0 +1,196 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/netguard"
+)
+
+// httptest servers listen on loopback, which the default client refuses to connect to
+func allowPrivate(t *testing.T) {
+	t.Helper()
+	blocking := client
+	client = netguard.NewClient(httpcommon.LinkPreviewConstants.RequestTimeout, maxRedirects, true, CheckURL)
+	t.Cleanup(func() { client = blocking })
+}
+
+func serveHTML(t *testing.T, pages map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParse(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/articles/1")
+
+	tests := []struct {
+		name string
+		html string
+		want *Preview
+		err  error
+	}{
+		{
+			name: "opengraph wins over twitter and title",
+			html: `<html><head><title>Plain</title>
+				<meta name="twitter:title" content="Twitter">
+				<meta property="og:title" content="  Open   Graph ">
+				<meta property="og:description" content="OG description">
+				<meta property="og:site_name" content="Example">
+				<meta property="og:image" content="/images/cover.png">
+				</head><body></body></html>`,
+			want: &Preview{Title: "Open Graph", Description: "OG description", SiteName: "Example", ImageURL: "https://example.com/images/cover.png"},
+		},
+		{
+			name: "twitter card when there's no opengraph",
+			html: `<head><title>Plain</title>
+				<meta name="twitter:title" content="Twitter">
+				<meta name="twitter:description" content="Twitter description">
+				<meta name="twitter:image:src" content="https://cdn.example.com/t.png"></head>`,
+			want: &Preview{Title: "Twitter", Description: "Twitter description", ImageURL: "https://cdn.example.com/t.png"},
+		},
+		{
+			name: "plain title and description",
+			html: `<head><title>Just a title</title><meta name="description" content="Just a description"></head>`,
+			want: &Preview{Title: "Just a title", Description: "Just a description"},
+		},
+		{
+			name: "metadata in the body is ignored",
+			html: `<head></head><body><meta property="og:title" content="Too late"></body>`,
+			err:  ErrNoMetadata,
+		},
+		{
+			name: "non-http image dropped",
+			html: `<head><title>T</title><meta property="og:image" content="javascript:alert(1)"></head>`,
+			want: &Preview{Title: "T"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(strings.NewReader(tt.html), pageURL)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("parse() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse() error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("parse() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchResolvesImageAfterRedirect(t *testing.T) {
+	allowPrivate(t)
+	server := serveHTML(t, map[string]string{
+		"/blog/post": `<head><meta property="og:title" content="Moved"><meta property="og:image" content="cover.png"></head>`,
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, server.URL+"/blog/post", http.StatusFound)
+	})
+	shortener := httptest.NewServer(mux)
+	defer shortener.Close()
+
+	preview, err := Fetch(context.Background(), shortener.URL+"/short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := server.URL + "/blog/cover.png"; preview.ImageURL != want {
+		t.Errorf("ImageURL = %q, want %q", preview.ImageURL, want)
+	}
+}
+
+func TestFetchRejectsNonHTML(t *testing.T) {
+	allowPrivate(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.7"))
+	}))
+	defer server.Close()
+
+	if _, err := Fetch(context.Background(), server.URL); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Fetch() = %v, want ErrNotHTML", err)
+	}
+}
+
+func TestFetchStopsAtMaxBodyBytes(t *testing.T) {
+	allowPrivate(t)
+	padding := strings.Repeat("a", int(httpcommon.LinkPreviewConstants.MaxBodyBytes))
+	server := serveHTML(t, map[string]string{
+		"/before": `<head><title>Early</title><!--` + padding + `--><meta property="og:title" content="Late"></head>`,
+		"/after":  `<head><!--` + padding + `--><title>Late</title></head>`,
+	})
+
+	preview, err := Fetch(context.Background(), server.URL+"/before")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Early" {
+		t.Errorf("Title = %q, want only what came before the cut-off", preview.Title)
+	}
+	if _, err = Fetch(context.Background(), server.URL+"/after"); !errors.Is(err, ErrNoMetadata) {
+		t.Errorf("Fetch() = %v, want ErrNoMetadata", err)
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	if httpcommon.LinkPreviewConstants.AllowPrivate {
+		t.Skip("LINK_PREVIEW_ALLOW_PRIVATE is set")
+	}
+	server := serveHTML(t, map[string]string{"/": `<head><title>Internal</title></head>`})
+
+	_, err := Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("Fetch() = %v, want ErrBlockedAddress", err)
+	}
+	if Retryable(err) {
+		t.Error("a blocked address shouldn't be retried")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid url", ErrInvalidURL, false},
+		{"blocked address", fmt.Errorf("dial: %w", ErrBlockedAddress), false},
+		{"not html", ErrNotHTML, false},
+		{"no metadata", ErrNoMetadata, false},
+		{"not found", &StatusError{StatusCode: http.StatusNotFound}, false},
+		{"server error", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"rate limited", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"network error", errors.New("connection reset by peer"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
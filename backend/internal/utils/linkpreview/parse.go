@@ -0,0 +1,140 @@
+package linkpreview
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// longest values kept, matching the link_previews columns
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxSiteNameLength    = 200
+	maxImageURLLength    = 2048
+)
+
+var ErrNoMetadata = errors.New("page has nothing to preview")
+
+// what a post shows for a link, fields the page didn't provide are left empty
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// read a preview out of a page's head: OpenGraph tags first, Twitter cards after them,
+// and the plain title and description for pages that have neither
+func parse(body io.Reader, pageURL *url.URL) (*Preview, error) {
+	meta := map[string]string{}
+	var title strings.Builder
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+
+		token := tokenizer.Token()
+		if tokenType == html.EndTagToken {
+			if token.DataAtom == atom.Head {
+				break
+			}
+			if token.DataAtom == atom.Title {
+				inTitle = false
+			}
+			continue
+		}
+		if tokenType == html.TextToken && inTitle {
+			title.WriteString(token.Data)
+			continue
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		switch token.DataAtom {
+		case atom.Body:
+			// pages that never close their head still don't keep metadata in the body
+			return build(meta, title.String(), pageURL)
+		case atom.Title:
+			inTitle = tokenType == html.StartTagToken
+		case atom.Meta:
+			var key, content string
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case "property", "name":
+					if key == "" {
+						key = strings.ToLower(strings.TrimSpace(attr.Val))
+					}
+				case "content":
+					content = attr.Val
+				}
+			}
+			// the first value wins, like it does for crawlers
+			if _, ok := meta[key]; key != "" && !ok {
+				meta[key] = content
+			}
+		}
+	}
+
+	return build(meta, title.String(), pageURL)
+}
+
+func build(meta map[string]string, title string, pageURL *url.URL) (*Preview, error) {
+	preview := &Preview{
+		Title:       truncate(firstOf(meta["og:title"], meta["twitter:title"], title), maxTitleLength),
+		Description: truncate(firstOf(meta["og:description"], meta["twitter:description"], meta["description"]), maxDescriptionLength),
+		SiteName:    truncate(firstOf(meta["og:site_name"]), maxSiteNameLength),
+		ImageURL:    resolveImage(firstOf(meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"]), pageURL),
+	}
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return nil, ErrNoMetadata
+	}
+
+	return preview, nil
+}
+
+// helper function to pick the first value that isn't blank, with its whitespace collapsed
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func truncate(value string, maxLength int) string {
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value
+	}
+	return string([]rune(value)[:maxLength-1]) + "…"
+}
+
+// image URLs may be relative to the page, only http(s) ones are kept since clients load them directly
+func resolveImage(rawURL string, pageURL *url.URL) string {
+	if rawURL == "" {
+		return ""
+	}
+	parsed, err := pageURL.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ""
+	}
+	resolved := parsed.String()
+	if len(resolved) > maxImageURLLength {
+		return ""
+	}
+	return resolved
+}
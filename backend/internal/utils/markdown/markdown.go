@@ -0,0 +1,273 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// renders the restricted Markdown dialect posts are written in:
+//   - paragraphs separated by blank lines, single line breaks are kept
+//   - "> " blockquotes, "- " / "* " bullet lists and "1. " numbered lists
+//   - ``` fenced code blocks
+//   - **strong**, *em* / _em_, ~~strikethrough~~, `code`
+//   - [text](url) links and bare http(s) URLs
+//
+// anything else (headings, images, raw HTML...) comes out as escaped text,
+// and the result is passed through Sanitize before being returned
+func Render(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+
+	var out strings.Builder
+	renderBlocks(&out, lines)
+
+	return Sanitize(out.String())
+}
+
+func renderBlocks(out *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			// everything up to the closing fence is shown verbatim
+			var code []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // skip the closing fence
+			out.WriteString("<pre><code>")
+			out.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			out.WriteString("</code></pre>")
+
+		case isQuoteLine(trimmed):
+			var quoted []string
+			for i < len(lines) && isQuoteLine(strings.TrimSpace(lines[i])) {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+				i++
+			}
+			out.WriteString("<blockquote>")
+			renderBlocks(out, quoted)
+			out.WriteString("</blockquote>")
+
+		case bulletItem(trimmed) != "":
+			out.WriteString("<ul>")
+			for i < len(lines) && bulletItem(strings.TrimSpace(lines[i])) != "" {
+				out.WriteString("<li>")
+				out.WriteString(renderInline(bulletItem(strings.TrimSpace(lines[i])), true))
+				out.WriteString("</li>")
+				i++
+			}
+			out.WriteString("</ul>")
+
+		case numberedItem(trimmed) != "":
+			out.WriteString("<ol>")
+			for i < len(lines) && numberedItem(strings.TrimSpace(lines[i])) != "" {
+				out.WriteString("<li>")
+				out.WriteString(renderInline(numberedItem(strings.TrimSpace(lines[i])), true))
+				out.WriteString("</li>")
+				i++
+			}
+			out.WriteString("</ol>")
+
+		default:
+			// a paragraph runs until a blank line or the start of another block
+			var paragraph []string
+			for i < len(lines) && startsParagraphLine(lines[i]) {
+				paragraph = append(paragraph, renderInline(strings.TrimSpace(lines[i]), true))
+				i++
+			}
+			out.WriteString("<p>")
+			out.WriteString(strings.Join(paragraph, "<br>"))
+			out.WriteString("</p>")
+		}
+	}
+}
+
+func startsParagraphLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" &&
+		!strings.HasPrefix(trimmed, "```") &&
+		!isQuoteLine(trimmed) &&
+		bulletItem(trimmed) == "" &&
+		numberedItem(trimmed) == ""
+}
+
+func isQuoteLine(line string) bool {
+	return strings.HasPrefix(line, ">")
+}
+
+// returns the text of a "- item" or "* item" line, or "" if it isn't one
+func bulletItem(line string) string {
+	if len(line) > 2 && (line[0] == '-' || line[0] == '*') && line[1] == ' ' {
+		return strings.TrimSpace(line[2:])
+	}
+	return ""
+}
+
+// returns the text of a "1. item" line, or "" if it isn't one
+func numberedItem(line string) string {
+	digits := 0
+	for digits < len(line) && digits < 9 && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && len(line) > digits+2 && line[digits] == '.' && line[digits+1] == ' ' {
+		return strings.TrimSpace(line[digits+2:])
+	}
+	return ""
+}
+
+// render the inline markup of a single line, links are left out when rendering a link's own text
+func renderInline(text string, allowLinks bool) string {
+	var out strings.Builder
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		// backslash escapes the next punctuation character
+		if r == '\\' && i+1 < len(runes) && unicode.IsPunct(runes[i+1]) {
+			out.WriteString(html.EscapeString(string(runes[i+1])))
+			i += 2
+			continue
+		}
+
+		if r == '`' {
+			if end := indexFrom(runes, i+1, "`"); end > i+1 {
+				out.WriteString("<code>" + html.EscapeString(string(runes[i+1:end])) + "</code>")
+				i = end + 1
+				continue
+			}
+		}
+
+		if allowLinks && r == '[' {
+			if textEnd := indexFrom(runes, i+1, "]("); textEnd > i+1 {
+				if urlEnd := indexFrom(runes, textEnd+2, ")"); urlEnd > textEnd+2 {
+					href := string(runes[textEnd+2 : urlEnd])
+					if IsSafeURL(href) {
+						out.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+						out.WriteString(renderInline(string(runes[i+1:textEnd]), false))
+						out.WriteString("</a>")
+						i = urlEnd + 1
+						continue
+					}
+				}
+			}
+		}
+
+		if allowLinks && (hasPrefixAt(runes, i, "http://") || hasPrefixAt(runes, i, "https://")) && (i == 0 || !isWordRune(runes[i-1])) {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			// punctuation at the end of a sentence isn't part of the link
+			for end > i && strings.ContainsRune(".,;:!?)'\"", runes[end-1]) {
+				end--
+			}
+			href := string(runes[i:end])
+			if IsSafeURL(href) {
+				out.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(href) + "</a>")
+				i = end
+				continue
+			}
+		}
+
+		if delimiter, tag := emphasisAt(runes, i); delimiter != "" {
+			start := i + len([]rune(delimiter))
+			if end := closingDelimiter(runes, start, delimiter); end > start {
+				out.WriteString("<" + tag + ">")
+				out.WriteString(renderInline(string(runes[start:end]), allowLinks))
+				out.WriteString("</" + tag + ">")
+				i = end + len([]rune(delimiter))
+				continue
+			}
+		}
+
+		out.WriteString(html.EscapeString(string(r)))
+		i++
+	}
+
+	return out.String()
+}
+
+// figure out if an emphasis delimiter starts at position i, and which tag it stands for
+func emphasisAt(runes []rune, i int) (string, string) {
+	switch {
+	case hasPrefixAt(runes, i, "**"):
+		return "**", "strong"
+	case hasPrefixAt(runes, i, "~~"):
+		return "~~", "del"
+	case runes[i] == '*':
+		return "*", "em"
+	case runes[i] == '_' && (i == 0 || !isWordRune(runes[i-1])):
+		// underscores inside words (snake_case) aren't emphasis
+		return "_", "em"
+	}
+	return "", ""
+}
+
+// find where an emphasis span closes, the content can't start or end with a space
+func closingDelimiter(runes []rune, start int, delimiter string) int {
+	if start >= len(runes) || unicode.IsSpace(runes[start]) {
+		return -1
+	}
+
+	for end := indexFrom(runes, start, delimiter); end > start; end = indexFrom(runes, end+1, delimiter) {
+		if unicode.IsSpace(runes[end-1]) {
+			continue
+		}
+		// a single '*' shouldn't close on the first half of a '**'
+		if delimiter == "*" && (hasPrefixAt(runes, end, "**") || runes[end-1] == '*') {
+			continue
+		}
+		after := end + len([]rune(delimiter))
+		if delimiter == "_" && after < len(runes) && isWordRune(runes[after]) {
+			continue
+		}
+		return end
+	}
+
+	return -1
+}
+
+// allow only links that can't run script when clicked
+func IsSafeURL(href string) bool {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto":
+		return parsed.Opaque != ""
+	}
+	return false
+}
+
+func indexFrom(runes []rune, from int, needle string) int {
+	if from > len(runes) {
+		return -1
+	}
+	index := strings.Index(string(runes[from:]), needle)
+	if index < 0 {
+		return -1
+	}
+	return from + len([]rune(string(runes[from:])[:index]))
+}
+
+func hasPrefixAt(runes []rune, i int, prefix string) bool {
+	return strings.HasPrefix(string(runes[i:]), prefix)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,98 @@
+package markdown
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var allowedTags = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Br:         true,
+	atom.Strong:     true,
+	atom.Em:         true,
+	atom.Del:        true,
+	atom.Code:       true,
+	atom.Pre:        true,
+	atom.Blockquote: true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Li:         true,
+	atom.A:          true,
+}
+
+// tags whose content is dropped along with them instead of being kept as text
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Template: true,
+}
+
+// reduce a fragment of HTML to the allowlisted tags, stripping every attribute
+// except safe link targets, and mark links as nofollow
+func Sanitize(fragment string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+
+	var out strings.Builder
+	for _, node := range nodes {
+		for _, clean := range sanitizeNode(node) {
+			html.Render(&out, clean)
+		}
+	}
+
+	return out.String()
+}
+
+// returns the nodes that should take the place of the given one
+func sanitizeNode(node *html.Node) []*html.Node {
+	switch node.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: node.Data}}
+	case html.ElementNode:
+		if droppedTags[node.DataAtom] {
+			return nil
+		}
+
+		var children []*html.Node
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			children = append(children, sanitizeNode(child)...)
+		}
+
+		// unknown tags are unwrapped, keeping their content
+		if !allowedTags[node.DataAtom] {
+			return children
+		}
+
+		clean := &html.Node{Type: html.ElementNode, Data: node.Data, DataAtom: node.DataAtom}
+		if node.DataAtom == atom.A {
+			href := ""
+			for _, attr := range node.Attr {
+				if attr.Namespace == "" && attr.Key == "href" {
+					href = attr.Val
+				}
+			}
+			if !IsSafeURL(href) {
+				return children
+			}
+			clean.Attr = []html.Attribute{
+				{Key: "href", Val: href},
+				{Key: "rel", Val: "nofollow noopener noreferrer"},
+			}
+		}
+		for _, child := range children {
+			clean.AppendChild(child)
+		}
+
+		return []*html.Node{clean}
+	default:
+		// comments, doctypes and the like
+		return nil
+	}
+}
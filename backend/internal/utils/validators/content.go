@@ -0,0 +1,81 @@
+package validators
+
+import (
+	"strings"
+	"unicode"
+
+	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
+	"chi-mysql-boilerplate/internal/utils/entities"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// custom validator for text that has to contain more than whitespace
+func ValidateNotBlank(fl validator.FieldLevel) bool {
+	return strings.TrimSpace(fl.Field().String()) != ""
+}
+
+// custom validator for the length of post content, in user-perceived characters
+// and in bytes so that it always fits in the database column
+func ValidateMaxChars(fl validator.FieldLevel) bool {
+	content := fl.Field().String()
+	return len(content) <= httpcommon.ContentConstants.MaxBytes &&
+		CountGraphemes(content) <= httpcommon.ContentConstants.MaxChars
+}
+
+// custom validator for the number of lines in post content
+func ValidateMaxLines(fl validator.FieldLevel) bool {
+	return strings.Count(fl.Field().String(), "\n")+1 <= httpcommon.ContentConstants.MaxLines
+}
+
+// custom validator for the number of links in post content
+func ValidateMaxLinks(fl validator.FieldLevel) bool {
+	return len(entities.Links(fl.Field().String())) <= httpcommon.ContentConstants.MaxLinks
+}
+
+// custom validator rejecting control characters other than tabs and line breaks,
+// along with the bidirectional overrides that can make text read differently than it's stored
+func ValidateNoControlChars(fl validator.FieldLevel) bool {
+	for _, r := range fl.Field().String() {
+		if r == '\n' || r == '\r' || r == '\t' {
+			continue
+		}
+		if unicode.IsControl(r) || (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069') {
+			return false
+		}
+	}
+	return true
+}
+
+const zeroWidthJoiner = '\u200d'
+
+// approximates the number of grapheme clusters in a piece of text without the full
+// Unicode segmentation tables: combining and spacing marks (the vowel signs of Indic scripts),
+// variation selectors, skin tone modifiers, emoji tag characters (subdivision flags such as
+// England's) and anything joined by a zero width joiner count towards the character before them,
+// regional indicators count once per flag and CRLF counts once
+func CountGraphemes(text string) int {
+	count := 0
+	var previous rune
+	joinNext := false
+	pendingIndicator := false
+
+	for _, r := range text {
+		extends := joinNext ||
+			r == zeroWidthJoiner ||
+			unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) ||
+			(r >= 0x1f3fb && r <= 0x1f3ff) ||
+			(r >= 0xe0020 && r <= 0xe007f) ||
+			(r == '\n' && previous == '\r') ||
+			(pendingIndicator && unicode.Is(unicode.Regional_Indicator, r))
+
+		if !extends {
+			count++
+		}
+		pendingIndicator = unicode.Is(unicode.Regional_Indicator, r) && !pendingIndicator
+		joinNext = r == zeroWidthJoiner
+		previous = r
+	}
+
+	return count
+}
@@ -0,0 +1,33 @@
+package validators
+
+import "testing"
+
+func TestCountGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello", 5},
+		{"crlf", "a\r\nb", 3},
+		{"combining accent", "é", 1},
+		{"devanagari spacing vowel sign", "कि", 1},
+		{"devanagari word", "हिंदी", 2},
+		{"tamil spacing vowel sign", "கொ", 1},
+		{"variation selector", "❤️", 1},
+		{"skin tone", "👍🏽", 1},
+		{"zwj family", "👨‍👩‍👧", 1},
+		{"regional indicator flags", "🇫🇷🇩🇪", 2},
+		{"england flag", "🏴\U000e0067\U000e0062\U000e0065\U000e006e\U000e0067\U000e007f", 1},
+		{"england flag and text", "🏴\U000e0067\U000e0062\U000e0065\U000e006e\U000e0067\U000e007f ok", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountGraphemes(tt.text); got != tt.want {
+				t.Errorf("CountGraphemes(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
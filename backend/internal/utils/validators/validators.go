@@ -2,7 +2,10 @@ package validators
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 
 	httpcommon "chi-mysql-boilerplate/internal/domain/http_common"
 	"chi-mysql-boilerplate/internal/utils/helpers"
@@ -17,8 +20,21 @@ type Validator struct {
 
 func NewValidator(helpers *helpers.Message) *Validator {
 	validator := validator.New()
+	// report fields under their JSON names
+	validator.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
 	// register any custom validator here
 	validator.RegisterValidation("pwdminlen", ValidatePassword)
+	validator.RegisterValidation("notblank", ValidateNotBlank)
+	validator.RegisterValidation("maxchars", ValidateMaxChars)
+	validator.RegisterValidation("maxlines", ValidateMaxLines)
+	validator.RegisterValidation("maxlinks", ValidateMaxLinks)
+	validator.RegisterValidation("nocontrolchars", ValidateNoControlChars)
 
 	return &Validator{
 		validator: validator,
@@ -36,6 +52,7 @@ func ValidatePassword(fl validator.FieldLevel) bool {
 func (v *Validator) BindJSONAndValidate(w http.ResponseWriter, r *http.Request, body interface{}) error {
 	if err := helpers.ReadJSON(w, r, body); err != nil {
 		v.HandleError(w, err)
+		return err
 	}
 
 	if err := v.validator.Struct(body); err != nil {
@@ -45,6 +62,24 @@ func (v *Validator) BindJSONAndValidate(w http.ResponseWriter, r *http.Request,
 	return nil
 }
 
+// validate a struct that didn't come from a request body, returns one error per failing field
+func (v *Validator) ValidateStruct(body interface{}) []httpcommon.Error {
+	err := v.validator.Struct(body)
+	if err == nil {
+		return nil
+	}
+
+	fieldErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []httpcommon.Error{{Message: err.Error(), Code: httpcommon.ErrorResponseCode.InvalidRequest}}
+	}
+	httpErrs := make([]httpcommon.Error, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		httpErrs[i] = fieldError(fieldErr)
+	}
+	return httpErrs
+}
+
 func (v *Validator) HandleError(w http.ResponseWriter, err error) {
 	var httpErr httpcommon.Error
 
@@ -61,10 +96,14 @@ func (v *Validator) HandleError(w http.ResponseWriter, err error) {
 			Code:    httpcommon.ErrorResponseCode.InvalidRequest,
 		}
 	case validator.ValidationErrors:
-		httpErr = httpcommon.Error{
-			Message: err.Error(),
-			Code:    httpcommon.ErrorResponseCode.InvalidRequest,
+		// one error per failing field, so clients can point at what needs fixing
+		httpErrs := make([]httpcommon.Error, len(t))
+		for i, fieldErr := range t {
+			httpErrs[i] = fieldError(fieldErr)
 		}
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(httpErrs...))
+		return
 	default:
 		httpErr = httpcommon.Error{
 			Message: err.Error(),
@@ -76,3 +115,37 @@ func (v *Validator) HandleError(w http.ResponseWriter, err error) {
 	helpers.MessageLogs.ErrorLog.Println(err)
 	helpers.WriteJSON(w, http.StatusBadRequest, httpcommon.NewErrorResponse(httpErr))
 }
+
+// turn a failed validation into an error with a readable message and a code specific to the rule
+func fieldError(fieldErr validator.FieldError) httpcommon.Error {
+	code, ok := httpcommon.CustomValidationErrCode[fieldErr.Tag()]
+	if !ok {
+		code = httpcommon.ErrorResponseCode.InvalidRequest
+	}
+
+	var message string
+	switch fieldErr.Tag() {
+	case "required":
+		message = fmt.Sprintf("%s is required", fieldErr.Field())
+	case "notblank":
+		message = fmt.Sprintf("%s cannot be blank", fieldErr.Field())
+	case "maxchars":
+		message = fmt.Sprintf("%s cannot be longer than %d characters", fieldErr.Field(), httpcommon.ContentConstants.MaxChars)
+	case "maxlines":
+		message = fmt.Sprintf("%s cannot have more than %d lines", fieldErr.Field(), httpcommon.ContentConstants.MaxLines)
+	case "maxlinks":
+		message = fmt.Sprintf("%s cannot have more than %d links", fieldErr.Field(), httpcommon.ContentConstants.MaxLinks)
+	case "nocontrolchars":
+		message = fmt.Sprintf("%s contains characters that aren't allowed", fieldErr.Field())
+	case "pwdminlen":
+		message = fmt.Sprintf("%s must be at least 6 characters long", fieldErr.Field())
+	default:
+		message = fieldErr.Error()
+	}
+
+	return httpcommon.Error{
+		Message: message,
+		Code:    code,
+		Field:   fieldErr.Namespace()[strings.Index(fieldErr.Namespace(), ".")+1:],
+	}
+}